@@ -0,0 +1,255 @@
+package expresspay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultBatchConcurrency = 4
+
+var ErrDuplicateAccountNo = errors.New("expresspay: AccountNo repeated in batch")
+
+// BatchOptions controls BatchCreateInvoices.
+type BatchOptions struct {
+	Concurrency int
+	// RateLimit caps how many invoices this batch creates per second across all workers; zero
+	// means no limit beyond Concurrency. It applies on top of the client's own WithRateLimit.
+	RateLimit float64
+	// Checkpoint, if set, records every AccountNo before and after its invoice is created, so a
+	// batch restarted after a crash skips what was already done.
+	Checkpoint BatchCheckpoint
+}
+
+// BatchEntry is the checkpoint record of one AccountNo. An entry without InvoiceNo was in flight
+// when the batch stopped; the invoice may or may not exist.
+type BatchEntry struct {
+	AccountNo  string      `json:"account_no"`
+	InvoiceNo  json.Number `json:"invoice_no,omitempty"`
+	InvoiceURL string      `json:"invoice_url,omitempty"`
+	Started    time.Time   `json:"started"`
+}
+
+type BatchCheckpoint interface {
+	Load(ctx context.Context) (map[string]BatchEntry, error)
+	Save(ctx context.Context, e BatchEntry) error
+}
+
+// FileBatchCheckpoint appends entries to a JSON Lines file; the last entry per AccountNo wins.
+type FileBatchCheckpoint struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (f *FileBatchCheckpoint) Load(ctx context.Context) (map[string]BatchEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := map[string]BatchEntry{}
+	file, err := os.Open(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		var e BatchEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// A torn last line from a crash mid-write; the entry before it is still valid.
+			continue
+		}
+		entries[e.AccountNo] = e
+	}
+	return entries, sc.Err()
+}
+
+func (f *FileBatchCheckpoint) Save(ctx context.Context, e BatchEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+type BatchResult struct {
+	Index      int
+	AccountNo  string
+	InvoiceNo  json.Number
+	InvoiceURL string
+	// Resumed is set when the invoice was created by an earlier run and taken from the checkpoint
+	// or found by AccountNo.
+	Resumed bool
+	Err     error
+}
+
+type BatchReport struct {
+	Results []BatchResult
+	Created int
+	Resumed int
+	Failed  int
+}
+
+// Err joins the errors of all failed items, or returns nil when every item succeeded.
+func (r *BatchReport) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("item %d (AccountNo %s): %w", res.Index, res.AccountNo, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BatchCreateInvoices creates invoices with up to opts.Concurrency requests in flight. Results
+// are in input order; a failed item does not stop the others. An AccountNo is invoiced at most
+// once: repeats within reqs fail with ErrDuplicateAccountNo, and with a checkpoint, accounts an
+// earlier run finished are skipped and those it left in flight are looked up with ListInvoices
+// before being created again.
+//
+// The returned error is only set when the checkpoint cannot be loaded.
+func (c *Client) BatchCreateInvoices(ctx context.Context, reqs []AddInvoiceRequest, opts BatchOptions) (*BatchReport, error) {
+	done := map[string]BatchEntry{}
+	if opts.Checkpoint != nil {
+		var err error
+		if done, err = opts.Checkpoint.Load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &BatchReport{Results: make([]BatchResult, len(reqs))}
+	seen := map[string]bool{}
+	var pending []int
+	for i, r := range reqs {
+		res := &report.Results[i]
+		res.Index, res.AccountNo = i, r.AccountNo
+		if seen[r.AccountNo] {
+			res.Err = ErrDuplicateAccountNo
+			continue
+		}
+		seen[r.AccountNo] = true
+		if e, ok := done[r.AccountNo]; ok && e.InvoiceNo != "" {
+			res.InvoiceNo, res.InvoiceURL, res.Resumed = e.InvoiceNo, e.InvoiceURL, true
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	limit := newTokenBucket(opts.RateLimit, 1)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c.batchCreate(ctx, reqs[i], done[reqs[i].AccountNo], opts.Checkpoint, limit, &report.Results[i])
+			}
+		}()
+	}
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, res := range report.Results {
+		switch {
+		case res.Err != nil:
+			report.Failed++
+		case res.Resumed:
+			report.Resumed++
+		default:
+			report.Created++
+		}
+	}
+	return report, nil
+}
+
+func (c *Client) batchCreate(ctx context.Context, r AddInvoiceRequest, prev BatchEntry, cp BatchCheckpoint, limit *tokenBucket, res *BatchResult) {
+	if err := ctx.Err(); err != nil {
+		res.Err = err
+		return
+	}
+	if !prev.Started.IsZero() {
+		inv, err := c.findBatchInvoice(ctx, r.AccountNo, prev.Started)
+		if err != nil {
+			res.Err = fmt.Errorf("resolve in-flight invoice: %w", err)
+			return
+		}
+		if inv != nil {
+			res.InvoiceNo, res.Resumed = inv.InvoiceNo, true
+			res.Err = saveBatchEntry(ctx, cp, BatchEntry{AccountNo: r.AccountNo, InvoiceNo: inv.InvoiceNo, Started: prev.Started})
+			return
+		}
+	}
+
+	if limit != nil {
+		if err := limit.wait(ctx); err != nil {
+			res.Err = err
+			return
+		}
+	}
+	entry := BatchEntry{AccountNo: r.AccountNo, Started: time.Now()}
+	if err := saveBatchEntry(ctx, cp, entry); err != nil {
+		res.Err = err
+		return
+	}
+	resp, err := c.CreateInvoice(ctx, r)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	res.InvoiceNo, res.InvoiceURL = resp.InvoiceNo, resp.InvoiceURL
+	entry.InvoiceNo, entry.InvoiceURL = resp.InvoiceNo, resp.InvoiceURL
+	res.Err = saveBatchEntry(ctx, cp, entry)
+}
+
+// findBatchInvoice looks for an invoice for accountNo created since started, allowing for clock
+// skew between us and the API.
+func (c *Client) findBatchInvoice(ctx context.Context, accountNo string, started time.Time) (*Invoice, error) {
+	since := started.Add(-5 * time.Minute)
+	invoices, err := c.ListInvoices(ctx, ListInvoicesParams{From: since, AccountNo: accountNo})
+	if err != nil {
+		return nil, err
+	}
+	for i := range invoices {
+		if invoices[i].AccountNo == accountNo && !invoices[i].Created.Before(since) {
+			return &invoices[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func saveBatchEntry(ctx context.Context, cp BatchCheckpoint, e BatchEntry) error {
+	if cp == nil {
+		return nil
+	}
+	return cp.Save(ctx, e)
+}
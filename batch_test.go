@@ -0,0 +1,162 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+func batchRequests(accounts ...string) []expresspay.AddInvoiceRequest {
+	reqs := make([]expresspay.AddInvoiceRequest, len(accounts))
+	for i, a := range accounts {
+		reqs[i] = expresspay.AddInvoiceRequest{AccountNo: a, Amount: expresspay.BYN(100)}
+	}
+	return reqs
+}
+
+func invoiceCount(t *testing.T, c *expresspay.Client, accountNo string) int {
+	t.Helper()
+	invoices, err := c.ListInvoices(context.Background(), expresspay.ListInvoicesParams{AccountNo: accountNo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(invoices)
+}
+
+func TestBatchCreateInvoices(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+
+	reqs := batchRequests("A-1", "A-2", "A-1", "A-3")
+	reqs = append(reqs, expresspay.AddInvoiceRequest{AccountNo: "A-4"})
+	report, err := c.BatchCreateInvoices(context.Background(), reqs, expresspay.BatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 3 || report.Failed != 2 || report.Resumed != 0 {
+		t.Errorf("%d created, %d failed, %d resumed", report.Created, report.Failed, report.Resumed)
+	}
+	if !errors.Is(report.Results[2].Err, expresspay.ErrDuplicateAccountNo) {
+		t.Errorf("repeated AccountNo: %v", report.Results[2].Err)
+	}
+	var verr *expresspay.ValidationError
+	if !errors.As(report.Results[4].Err, &verr) || verr.Field("Amount") == nil {
+		t.Errorf("missing amount: %v", report.Results[4].Err)
+	}
+	if report.Results[0].InvoiceNo == "" || report.Results[3].AccountNo != "A-3" {
+		t.Errorf("results %+v", report.Results)
+	}
+	if n := invoiceCount(t, c, "A-1"); n != 1 {
+		t.Errorf("A-1 has %d invoices", n)
+	}
+}
+
+func TestBatchCreateInvoicesResume(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	cp := &expresspay.FileBatchCheckpoint{Path: filepath.Join(t.TempDir(), "batch.jsonl")}
+
+	if _, err := c.BatchCreateInvoices(ctx, batchRequests("A-1", "A-2"), expresspay.BatchOptions{Checkpoint: cp}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A-3 was created by a run that crashed before recording the invoice number; A-4 was in
+	// flight but never reached the API.
+	if _, err := c.CreateInvoice(ctx, batchRequests("A-3")[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []string{"A-3", "A-4"} {
+		if err := cp.Save(ctx, expresspay.BatchEntry{AccountNo: a, Started: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := c.BatchCreateInvoices(ctx, batchRequests("A-1", "A-2", "A-3", "A-4"), expresspay.BatchOptions{Checkpoint: cp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if report.Resumed != 3 || report.Created != 1 || !report.Results[2].Resumed || report.Results[3].Resumed {
+		t.Errorf("%d resumed, %d created: %+v", report.Resumed, report.Created, report.Results)
+	}
+	for _, a := range []string{"A-1", "A-2", "A-3", "A-4"} {
+		if n := invoiceCount(t, c, a); n != 1 {
+			t.Errorf("%s has %d invoices", a, n)
+		}
+	}
+
+	entries, err := cp.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := entries["A-4"]; e.InvoiceNo == "" || e.InvoiceNo != report.Results[3].InvoiceNo {
+		t.Errorf("checkpoint entry %+v", e)
+	}
+}
+
+// However many workers run, repeats of an AccountNo never reach the API.
+func TestBatchCreateInvoicesDuplicates(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+
+	reqs := batchRequests("A-1", "A-1", "A-2", "A-1", "A-2")
+	report, err := c.BatchCreateInvoices(context.Background(), reqs, expresspay.BatchOptions{Concurrency: len(reqs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range report.Results {
+		duplicate := i == 1 || i == 3 || i == 4
+		if duplicate != errors.Is(res.Err, expresspay.ErrDuplicateAccountNo) {
+			t.Errorf("item %d (%s): %v", i, res.AccountNo, res.Err)
+		}
+	}
+	if report.Created != 2 || report.Failed != 3 {
+		t.Errorf("%d created, %d failed", report.Created, report.Failed)
+	}
+	for _, a := range []string{"A-1", "A-2"} {
+		if n := invoiceCount(t, c, a); n != 1 {
+			t.Errorf("%s has %d invoices", a, n)
+		}
+	}
+}
+
+func TestBatchCreateInvoicesRateLimit(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+
+	start := time.Now()
+	report, err := c.BatchCreateInvoices(context.Background(), batchRequests("A-1", "A-2", "A-3", "A-4", "A-5"),
+		expresspay.BatchOptions{Concurrency: 5, RateLimit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	// One invoice right away, then one every 20ms.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("5 invoices at 50 per second took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err = c.BatchCreateInvoices(ctx, batchRequests("B-1"), expresspay.BatchOptions{RateLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(report.Results[0].Err, context.Canceled) || invoiceCount(t, c, "B-1") != 0 {
+		t.Errorf("canceled batch: %v", report.Results[0].Err)
+	}
+}
@@ -0,0 +1,178 @@
+package expresspay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("expresspay: circuit breaker open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig configures WithCircuitBreaker. Zero values take the defaults.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive transport errors or 5xx responses that opens
+	// the circuit. Default 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before letting probes through. Default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is both the number of probe requests allowed at once while half-open and
+	// the number of successful probes that close the circuit. Default 1.
+	HalfOpenProbes int
+	// OnStateChange is called synchronously on every transition.
+	OnStateChange func(from, to CircuitState)
+}
+
+// WithCircuitBreaker makes requests fail fast with ErrCircuitOpen while the API looks down.
+// Every attempt, including retries, passes through the breaker. Responses below 500 count as
+// successes as soon as their headers arrive since they prove the API is up, but a body that
+// breaks off while being read counts as a failure; attempts cut short by the context are not
+// counted.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		if cfg.FailureThreshold <= 0 {
+			cfg.FailureThreshold = 5
+		}
+		if cfg.OpenTimeout <= 0 {
+			cfg.OpenTimeout = 30 * time.Second
+		}
+		if cfg.HalfOpenProbes <= 0 {
+			cfg.HalfOpenProbes = 1
+		}
+		c.breaker = &circuitBreaker{cfg: cfg}
+	}
+}
+
+// CircuitState reports the breaker state, or CircuitClosed when no breaker is configured.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+type attemptOutcome int
+
+const (
+	attemptSucceeded attemptOutcome = iota
+	attemptFailed
+	attemptIgnored
+)
+
+// attemptResult classifies one attempt for the breaker: transport errors, including a body
+// that breaks off, and 5xx responses are failures, anything the API answered in full below 500
+// is a success, and a canceled context says nothing about the API.
+func attemptResult(ctx context.Context, status int, err error) attemptOutcome {
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return attemptIgnored
+	case err != nil:
+		return attemptFailed
+	case status >= http.StatusInternalServerError:
+		return attemptFailed
+	}
+	return attemptSucceeded
+}
+
+// allow admits an attempt and returns the function that reports its outcome. Only the first
+// report settles a half-open probe; a later one, such as a failure while reading a body already
+// reported as a success, counts as a plain outcome.
+func (b *circuitBreaker) allow() (func(attemptOutcome), error) {
+	if b == nil {
+		return func(attemptOutcome) {}, nil
+	}
+	b.mu.Lock()
+	from := b.state
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state, b.probes, b.successes = CircuitHalfOpen, 0, 0
+	}
+	probe := false
+	switch b.state {
+	case CircuitOpen:
+		b.mu.Unlock()
+		return nil, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			b.mu.Unlock()
+			b.notify(from, CircuitHalfOpen)
+			return nil, ErrCircuitOpen
+		}
+		b.probes++
+		probe = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+	return func(o attemptOutcome) {
+		b.record(o, probe)
+		probe = false
+	}, nil
+}
+
+func (b *circuitBreaker) record(o attemptOutcome, probe bool) {
+	b.mu.Lock()
+	from := b.state
+	if probe && b.state == CircuitHalfOpen {
+		b.probes--
+	}
+	switch o {
+	case attemptSucceeded:
+		b.failures = 0
+		if b.state == CircuitHalfOpen && probe {
+			b.successes++
+			if b.successes >= b.cfg.HalfOpenProbes {
+				b.state = CircuitClosed
+			}
+		}
+	case attemptFailed:
+		b.failures++
+		if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.cfg.FailureThreshold) {
+			b.state = CircuitOpen
+			b.openedAt = time.Now()
+			b.failures = 0
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,129 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+type transitions struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (tr *transitions) record(from, to expresspay.CircuitState) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.log = append(tr.log, from.String()+">"+to.String())
+}
+
+func (tr *transitions) String() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return strings.Join(tr.log, " ")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy bool
+	var mu sync.Mutex
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"Status": 1}`))
+	})
+	var tr transitions
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithCircuitBreaker(expresspay.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+		OnStateChange:    tr.record,
+	}))
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := c.GetInvoiceStatus(ctx, 1); !errors.Is(err, expresspay.ErrServerError) {
+			t.Fatalf("err = %v", err)
+		}
+	}
+	if _, err := c.GetInvoiceStatus(ctx, 1); !errors.Is(err, expresspay.ErrCircuitOpen) {
+		t.Fatalf("open circuit: err = %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("%d requests reached the server", hits.Load())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	if _, err := c.GetInvoiceStatus(ctx, 1); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if c.CircuitState() != expresspay.CircuitClosed {
+		t.Errorf("state %v after a successful probe", c.CircuitState())
+	}
+	if got := tr.String(); got != "closed>open open>half-open half-open>closed" {
+		t.Errorf("transitions %s", got)
+	}
+}
+
+func TestCircuitBreakerClientErrors(t *testing.T) {
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithCircuitBreaker(expresspay.CircuitBreakerConfig{FailureThreshold: 1}))
+	for range 3 {
+		if _, err := c.GetInvoiceStatus(context.Background(), 1); !errors.Is(err, expresspay.ErrNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	}
+	if c.CircuitState() != expresspay.CircuitClosed {
+		t.Errorf("4xx responses opened the circuit")
+	}
+}
+
+// A streamed list whose body breaks off is a transport failure even though the headers arrived
+// with status 200.
+func TestCircuitBreakerBrokenBody(t *testing.T) {
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte(`{"Items": [{"InvoiceNo": 1, "Amount": "1,00", "Currency": 933}, `))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithCircuitBreaker(expresspay.CircuitBreakerConfig{FailureThreshold: 1}))
+	var failed bool
+	for _, err := range c.InvoicesSeq(context.Background(), expresspay.ListInvoicesParams{}) {
+		if err != nil {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatal("truncated body accepted")
+	}
+	if c.CircuitState() != expresspay.CircuitOpen {
+		t.Errorf("state %v after a broken body", c.CircuitState())
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceled(t *testing.T) {
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithCircuitBreaker(expresspay.CircuitBreakerConfig{FailureThreshold: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetInvoiceStatus(ctx, 1); err == nil {
+		t.Fatal("want error")
+	}
+	if c.CircuitState() != expresspay.CircuitClosed {
+		t.Errorf("a canceled request opened the circuit")
+	}
+}
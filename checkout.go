@@ -0,0 +1,127 @@
+package expresspay
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/url"
+)
+
+// CheckoutForm is a signed web_invoices / web_cardinvoices request meant to be POSTed by the
+// customer's browser (ReturnType "redirect").
+type CheckoutForm struct {
+	// ID is the id of the rendered form element. WebInvoiceForm and WebCardInvoiceForm derive
+	// it from the account number so that several forms can share a page.
+	ID     string
+	Action string
+	Fields url.Values
+}
+
+var checkoutTemplate = template.Must(template.New("checkout").Parse(`<form id="{{.ID}}" method="post" action="{{.Action}}" accept-charset="utf-8">
+{{- range .Fields}}
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{- end}}
+<noscript><button type="submit">{{.Button}}</button></noscript>
+</form>
+<script>document.getElementById({{.ID}}).submit();</script>
+`))
+
+// WebInvoiceForm builds the signed fields CreateWebInvoice would send. An empty ReturnType is
+// set to "redirect".
+func (c *Client) WebInvoiceForm(r AddWebInvoiceRequest) (*CheckoutForm, error) {
+	if r.ReturnType == "" {
+		r.ReturnType = "redirect"
+	}
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	form, err := c.webInvoiceForm(r)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckoutForm{ID: checkoutID("invoice", r.AccountNo), Action: c.BaseURL + "web_invoices", Fields: form}, nil
+}
+
+// WebCardInvoiceForm is the CreateWebCardInvoice counterpart of WebInvoiceForm.
+func (c *Client) WebCardInvoiceForm(r AddWebCardInvoiceRequest) (*CheckoutForm, error) {
+	if r.ReturnType == "" {
+		r.ReturnType = "redirect"
+	}
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	form, err := c.webCardInvoiceForm(r)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckoutForm{ID: checkoutID("card", r.AccountNo), Action: c.BaseURL + "web_cardinvoices", Fields: form}, nil
+}
+
+// checkoutFieldNames lists the fields of web_invoices and web_cardinvoices that may appear in a
+// page sent to the customer. Anything else in CheckoutForm.Fields, notably Token, is never
+// rendered.
+var checkoutFieldNames = []string{
+	"ServiceId", "AccountNo", "Amount", "Currency", "Expiration", "ExpirationDate", "Info",
+	"Surname", "FirstName", "Patronymic", "City", "Street", "House", "Building", "Apartment",
+	"IsNameEditable", "IsAddressEditable", "IsAmountEditable", "EmailNotification", "SmsPhone",
+	"Language", "SessionTimeoutSecs", "ReturnType", "ReturnUrl", "FailUrl", "ReturnInvoiceUrl",
+	"Signature",
+}
+
+// checkoutID builds an element id from the account number, replacing characters that are not
+// safe in an id or a CSS selector.
+func checkoutID(kind, accountNo string) string {
+	id := []byte("expresspay-" + kind + "-")
+	for _, r := range accountNo {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			id = append(id, byte(r))
+		default:
+			id = append(id, '_')
+		}
+	}
+	return string(id)
+}
+
+type checkoutField struct {
+	Name, Value string
+}
+
+// Render writes a form that submits itself on load, with a button fallback for browsers
+// without JavaScript.
+func (f *CheckoutForm) Render(w io.Writer) error {
+	var fields []checkoutField
+	for _, name := range checkoutFieldNames {
+		for _, v := range f.Fields[name] {
+			fields = append(fields, checkoutField{Name: name, Value: v})
+		}
+	}
+	id := f.ID
+	if id == "" {
+		id = "expresspay-checkout"
+	}
+	return checkoutTemplate.Execute(w, struct {
+		ID     string
+		Action string
+		Button string
+		Fields []checkoutField
+	}{
+		ID:     id,
+		Action: f.Action,
+		Button: "Pay",
+		Fields: fields,
+	})
+}
+
+// HTML returns the rendered form for embedding in another html/template.
+func (f *CheckoutForm) HTML() (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,93 @@
+package expresspay_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+)
+
+func TestCheckoutFormOmitsToken(t *testing.T) {
+	const token = "secret-api-token-4711"
+	c := expresspay.NewClient("https://api.example/v1/", token, "secret")
+
+	web, err := c.WebInvoiceForm(webInvoiceRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	card, err := c.WebCardInvoiceForm(expresspay.AddWebCardInvoiceRequest{
+		ServiceID: "17",
+		AccountNo: "order-1",
+		Amount:    expresspay.BYN(1500),
+		Info:      "Order 1",
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, form := range []*expresspay.CheckoutForm{web, card} {
+		form.Fields.Set("Token", token)
+		var sb strings.Builder
+		if err := form.Render(&sb); err != nil {
+			t.Fatal(err)
+		}
+		html := sb.String()
+		if strings.Contains(html, token) {
+			t.Errorf("%s: rendered form contains the API token:\n%s", form.Action, html)
+		}
+		for _, want := range []string{`name="AccountNo" value="order-1"`, `name="Amount" value="15,00"`, `name="Signature"`} {
+			if !strings.Contains(html, want) {
+				t.Errorf("%s: rendered form lacks %s:\n%s", form.Action, want, html)
+			}
+		}
+	}
+}
+
+// Two forms on one page must not share an element id, or both scripts would submit the first.
+func TestCheckoutFormIDs(t *testing.T) {
+	c := expresspay.NewClient("https://api.example/v1/", "token", "secret")
+	ids := map[string]bool{}
+	for _, account := range []string{"order-1", "order-2", "order 3/\"x\""} {
+		req := webInvoiceRequest()
+		req.AccountNo = account
+		web, err := c.WebInvoiceForm(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		card, err := c.WebCardInvoiceForm(expresspay.AddWebCardInvoiceRequest{
+			ServiceID: "17",
+			AccountNo: account,
+			Amount:    expresspay.BYN(1500),
+			Info:      "Order",
+			ReturnURL: "https://shop.example/ok",
+			FailURL:   "https://shop.example/fail",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, form := range []*expresspay.CheckoutForm{web, card} {
+			if ids[form.ID] || strings.ContainsAny(form.ID, " /\"") {
+				t.Errorf("%s: id %q", account, form.ID)
+			}
+			ids[form.ID] = true
+			var sb strings.Builder
+			if err := form.Render(&sb); err != nil {
+				t.Fatal(err)
+			}
+			html := sb.String()
+			if !strings.Contains(html, `<form id="`+form.ID+`"`) || !strings.Contains(html, `getElementById("`+form.ID+`")`) {
+				t.Errorf("%s: form and script do not use id %q:\n%s", account, form.ID, html)
+			}
+		}
+	}
+
+	custom := &expresspay.CheckoutForm{ID: "pay-now", Action: "https://api.example/v1/web_invoices"}
+	var sb strings.Builder
+	if err := custom.Render(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `<form id="pay-now"`) {
+		t.Errorf("caller's id ignored:\n%s", sb.String())
+	}
+}
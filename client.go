@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"time"
 )
 
 const (
@@ -17,12 +20,24 @@ const (
 )
 
 type Client struct {
-	BaseURL       string
-	Token         string
-	Secret        string
-	HTTPClient    *http.Client
-	UseSignature  bool
-	SignatureFunc SignatureFunc
+	BaseURL        string
+	Token          string
+	Secret         string
+	HTTPClient     *http.Client
+	UseSignature   bool
+	SignatureFunc  SignatureFunc
+	Retry          RetryPolicy
+	ListWindowDays int
+	SkipValidation bool
+	Logger         *slog.Logger
+	Store          Store
+	Metrics        Metrics
+
+	VerifyResponseSignature bool
+
+	accountLocks *keyLocks
+	limits       *requestLimiter
+	breaker      *circuitBreaker
 }
 
 type Option func(*Client)
@@ -41,6 +56,12 @@ func WithSignature() Option {
 	}
 }
 
+func WithResponseSignatureVerification(enabled bool) Option {
+	return func(c *Client) {
+		c.VerifyResponseSignature = enabled
+	}
+}
+
 func WithSignatureFunc(fn SignatureFunc) Option {
 	return func(c *Client) {
 		if fn != nil {
@@ -63,6 +84,10 @@ func NewClient(baseURL, token, secret string, opts ...Option) *Client {
 		HTTPClient:    http.DefaultClient,
 		UseSignature:  secret != "",
 		SignatureFunc: DefaultSignature,
+
+		VerifyResponseSignature: secret != "",
+
+		accountLocks: &keyLocks{},
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -71,25 +96,12 @@ func NewClient(baseURL, token, secret string, opts ...Option) *Client {
 }
 
 func (c *Client) ListInvoices(ctx context.Context, p ListInvoicesParams) ([]Invoice, error) {
-	query := url.Values{}
-	query.Set("token", c.Token)
-	addIfNotEmpty(query, "From", p.From)
-	addIfNotEmpty(query, "To", p.To)
-	addIfNotEmpty(query, "AccountNo", p.AccountNo)
-	addIfNotEmpty(query, "Status", p.Status)
-
-	sigParams := map[string]string{
-		"Token":     c.Token,
-		"From":      p.From,
-		"To":        p.To,
-		"AccountNo": p.AccountNo,
-		"Status":    p.Status,
-	}
-	if err := c.applySignature("get-list-invoices", sigParams, query, nil, true); err != nil {
+	query, err := c.listInvoicesQuery(p)
+	if err != nil {
 		return nil, err
 	}
 
-	data, err := c.do(ctx, http.MethodGet, "invoices", query, nil)
+	data, err := c.do(ctx, "get-list-invoices", http.MethodGet, "invoices", query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -99,17 +111,46 @@ func (c *Client) ListInvoices(ctx context.Context, p ListInvoicesParams) ([]Invo
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	for i := range resp.Items {
+		resp.Items[i].fillCurrency()
+	}
 	return resp.Items, nil
 }
 
+func (c *Client) listInvoicesQuery(p ListInvoicesParams) (url.Values, error) {
+	query := url.Values{}
+	query.Set("token", c.Token)
+	addIfNotEmpty(query, "From", formatDate(p.From))
+	addIfNotEmpty(query, "To", formatDate(p.To))
+	addIfNotEmpty(query, "AccountNo", p.AccountNo)
+	addIfNotEmpty(query, "Status", string(p.Status))
+
+	sigParams := map[string]string{
+		"Token":     c.Token,
+		"From":      formatDate(p.From),
+		"To":        formatDate(p.To),
+		"AccountNo": p.AccountNo,
+		"Status":    string(p.Status),
+	}
+	if err := c.applySignature("get-list-invoices", sigParams, query, nil, true); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
 func (c *Client) CreateInvoice(ctx context.Context, r AddInvoiceRequest) (*AddInvoiceResponse, error) {
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	query := url.Values{}
 	query.Set("token", c.Token)
 	form := url.Values{}
 	form.Set("AccountNo", r.AccountNo)
-	form.Set("Amount", r.Amount)
-	form.Set("Currency", r.Currency)
-	addIfNotEmpty(form, "Expiration", r.Expiration)
+	form.Set("Amount", r.Amount.String())
+	form.Set("Currency", r.Amount.Currency)
+	addIfNotEmpty(form, "Expiration", formatDate(r.Expiration))
 	addIfNotEmpty(form, "Info", r.Info)
 	addIfNotEmpty(form, "Surname", r.Surname)
 	addIfNotEmpty(form, "FirstName", r.FirstName)
@@ -129,9 +170,9 @@ func (c *Client) CreateInvoice(ctx context.Context, r AddInvoiceRequest) (*AddIn
 	sigParams := map[string]string{
 		"Token":             c.Token,
 		"AccountNo":         r.AccountNo,
-		"Amount":            r.Amount,
-		"Currency":          r.Currency,
-		"Expiration":        r.Expiration,
+		"Amount":            r.Amount.String(),
+		"Currency":          r.Amount.Currency,
+		"Expiration":        formatDate(r.Expiration),
 		"Info":              r.Info,
 		"Surname":           r.Surname,
 		"FirstName":         r.FirstName,
@@ -152,7 +193,7 @@ func (c *Client) CreateInvoice(ctx context.Context, r AddInvoiceRequest) (*AddIn
 		return nil, err
 	}
 
-	data, err := c.do(ctx, http.MethodPost, "invoices", query, form)
+	data, err := c.do(ctx, "add-invoice", http.MethodPost, "invoices", query, form)
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +201,8 @@ func (c *Client) CreateInvoice(ctx context.Context, r AddInvoiceRequest) (*AddIn
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	c.metrics().InvoiceCreated(InvoiceKindERIP)
+	c.storeInvoice(ctx, InvoiceKindERIP, resp.InvoiceNo, r.AccountNo, r.Amount, r)
 	return &resp, nil
 }
 
@@ -176,7 +219,7 @@ func (c *Client) GetInvoice(ctx context.Context, invoiceNo int) (*InvoiceDetails
 	}
 
 	path := fmt.Sprintf("invoices/%d", invoiceNo)
-	data, err := c.do(ctx, http.MethodGet, path, query, nil)
+	data, err := c.do(ctx, "get-details-invoice", http.MethodGet, path, query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +227,8 @@ func (c *Client) GetInvoice(ctx context.Context, invoiceNo int) (*InvoiceDetails
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	resp.fillCurrency()
+	c.storeStatus(ctx, InvoiceKindERIP, invoiceNo, resp.Status)
 	return &resp, nil
 }
 
@@ -200,7 +245,7 @@ func (c *Client) GetInvoiceStatus(ctx context.Context, invoiceNo int) (*InvoiceS
 	}
 
 	path := fmt.Sprintf("invoices/%d/status", invoiceNo)
-	data, err := c.do(ctx, http.MethodGet, path, query, nil)
+	data, err := c.do(ctx, "status-invoice", http.MethodGet, path, query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +253,7 @@ func (c *Client) GetInvoiceStatus(ctx context.Context, invoiceNo int) (*InvoiceS
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	c.storeStatus(ctx, InvoiceKindERIP, invoiceNo, resp.Status)
 	return &resp, nil
 }
 
@@ -224,28 +270,21 @@ func (c *Client) CancelInvoice(ctx context.Context, invoiceNo int) error {
 	}
 
 	path := fmt.Sprintf("invoices/%d", invoiceNo)
-	_, err := c.do(ctx, http.MethodDelete, path, query, nil)
-	return err
+	if _, err := c.do(ctx, "cancel-invoice", http.MethodDelete, path, query, nil); err != nil {
+		return err
+	}
+	c.metrics().InvoiceCanceled()
+	c.storeStatus(ctx, InvoiceKindERIP, invoiceNo, InvoiceStatusCanceled)
+	return nil
 }
 
 func (c *Client) ListPayments(ctx context.Context, p ListPaymentsParams) ([]Payment, error) {
-	query := url.Values{}
-	query.Set("token", c.Token)
-	addIfNotEmpty(query, "From", p.From)
-	addIfNotEmpty(query, "To", p.To)
-	addIfNotEmpty(query, "AccountNo", p.AccountNo)
-
-	sigParams := map[string]string{
-		"Token":     c.Token,
-		"From":      p.From,
-		"To":        p.To,
-		"AccountNo": p.AccountNo,
-	}
-	if err := c.applySignature("get-list-payments", sigParams, query, nil, true); err != nil {
+	query, err := c.listPaymentsQuery(p)
+	if err != nil {
 		return nil, err
 	}
 
-	data, err := c.do(ctx, http.MethodGet, "payments", query, nil)
+	data, err := c.do(ctx, "get-list-payments", http.MethodGet, "payments", query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -255,9 +294,31 @@ func (c *Client) ListPayments(ctx context.Context, p ListPaymentsParams) ([]Paym
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	for i := range resp.Items {
+		resp.Items[i].fillCurrency()
+	}
 	return resp.Items, nil
 }
 
+func (c *Client) listPaymentsQuery(p ListPaymentsParams) (url.Values, error) {
+	query := url.Values{}
+	query.Set("token", c.Token)
+	addIfNotEmpty(query, "From", formatDate(p.From))
+	addIfNotEmpty(query, "To", formatDate(p.To))
+	addIfNotEmpty(query, "AccountNo", p.AccountNo)
+
+	sigParams := map[string]string{
+		"Token":     c.Token,
+		"From":      formatDate(p.From),
+		"To":        formatDate(p.To),
+		"AccountNo": p.AccountNo,
+	}
+	if err := c.applySignature("get-list-payments", sigParams, query, nil, true); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
 func (c *Client) GetPayment(ctx context.Context, paymentNo int) (*PaymentDetails, error) {
 	query := url.Values{}
 	query.Set("token", c.Token)
@@ -271,7 +332,7 @@ func (c *Client) GetPayment(ctx context.Context, paymentNo int) (*PaymentDetails
 	}
 
 	path := fmt.Sprintf("payments/%d", paymentNo)
-	data, err := c.do(ctx, http.MethodGet, path, query, nil)
+	data, err := c.do(ctx, "get-details-payment", http.MethodGet, path, query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -279,6 +340,7 @@ func (c *Client) GetPayment(ctx context.Context, paymentNo int) (*PaymentDetails
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	resp.fillCurrency()
 	return &resp, nil
 }
 
@@ -301,7 +363,7 @@ func (c *Client) GetQRCode(ctx context.Context, invoiceID int, p QRCodeParams) (
 		return nil, err
 	}
 
-	data, err := c.do(ctx, http.MethodGet, "qrcode/getqrcode", query, nil)
+	data, err := c.do(ctx, "get-qr-code", http.MethodGet, "qrcode/getqrcode", query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -313,40 +375,45 @@ func (c *Client) GetQRCode(ctx context.Context, invoiceID int, p QRCodeParams) (
 }
 
 func (c *Client) CreateCardInvoice(ctx context.Context, r AddCardInvoiceRequest) (*AddCardInvoiceResponse, error) {
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	query := url.Values{}
 	query.Set("token", c.Token)
 	form := url.Values{}
 	form.Set("AccountNo", r.AccountNo)
-	form.Set("Amount", r.Amount)
-	form.Set("Currency", r.Currency)
+	form.Set("Amount", r.Amount.String())
+	form.Set("Currency", r.Amount.Currency)
 	form.Set("Info", r.Info)
 	form.Set("ReturnUrl", r.ReturnURL)
 	form.Set("FailUrl", r.FailURL)
-	addIfNotEmpty(form, "Expiration", r.Expiration)
+	addIfNotEmpty(form, "Expiration", formatDate(r.Expiration))
 	addIfNotEmpty(form, "Language", r.Language)
 	addIfNotEmpty(form, "SessionTimeoutSecs", r.SessionTimeoutSecs)
-	addIfNotEmpty(form, "ExpirationDate", r.ExpirationDate)
+	addIfNotEmpty(form, "ExpirationDate", formatDateTime(r.ExpirationDate))
 	addIfNotEmpty(form, "ReturnInvoiceUrl", r.ReturnInvoiceURL)
 
 	sigParams := map[string]string{
 		"Token":              c.Token,
 		"AccountNo":          r.AccountNo,
-		"Expiration":         r.Expiration,
-		"Amount":             r.Amount,
-		"Currency":           r.Currency,
+		"Expiration":         formatDate(r.Expiration),
+		"Amount":             r.Amount.String(),
+		"Currency":           r.Amount.Currency,
 		"Info":               r.Info,
 		"ReturnUrl":          r.ReturnURL,
 		"FailUrl":            r.FailURL,
 		"Language":           r.Language,
 		"SessionTimeoutSecs": r.SessionTimeoutSecs,
-		"ExpirationDate":     r.ExpirationDate,
+		"ExpirationDate":     formatDateTime(r.ExpirationDate),
 		"ReturnInvoiceUrl":   r.ReturnInvoiceURL,
 	}
 	if err := c.applySignature("add-card-invoice", sigParams, query, nil, true); err != nil {
 		return nil, err
 	}
 
-	data, err := c.do(ctx, http.MethodPost, "cardinvoices", query, form)
+	data, err := c.do(ctx, "add-card-invoice", http.MethodPost, "cardinvoices", query, form)
 	if err != nil {
 		return nil, err
 	}
@@ -357,6 +424,8 @@ func (c *Client) CreateCardInvoice(ctx context.Context, r AddCardInvoiceRequest)
 	if err := resp.check(); err != nil {
 		return nil, err
 	}
+	c.metrics().InvoiceCreated(InvoiceKindCard)
+	c.storeInvoice(ctx, InvoiceKindCard, resp.CardInvoiceNo, r.AccountNo, r.Amount, r)
 	return &resp, nil
 }
 
@@ -373,7 +442,7 @@ func (c *Client) GetCardInvoicePaymentForm(ctx context.Context, cardInvoiceNo in
 	}
 
 	path := fmt.Sprintf("cardinvoices/%d/payment", cardInvoiceNo)
-	data, err := c.do(ctx, http.MethodGet, path, query, nil)
+	data, err := c.do(ctx, "card-invoice-form", http.MethodGet, path, query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -402,7 +471,7 @@ func (c *Client) GetCardInvoiceStatus(ctx context.Context, cardInvoiceNo int, la
 	}
 
 	path := fmt.Sprintf("cardinvoices/%d/status", cardInvoiceNo)
-	data, err := c.do(ctx, http.MethodGet, path, query, nil)
+	data, err := c.do(ctx, "status-card-invoice", http.MethodGet, path, query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -413,6 +482,9 @@ func (c *Client) GetCardInvoiceStatus(ctx context.Context, cardInvoiceNo int, la
 	if err := resp.check(); err != nil {
 		return nil, err
 	}
+	resp.fillCurrency()
+	// Declined and failed registrations have no InvoiceStatus counterpart and are not recorded.
+	c.storeStatus(ctx, InvoiceKindCard, cardInvoiceNo, resp.CardInvoiceStatus.InvoiceStatus())
 	return &resp, nil
 }
 
@@ -432,7 +504,7 @@ func (c *Client) ReverseCardInvoice(ctx context.Context, cardInvoiceNo int) (*Ca
 	}
 
 	path := fmt.Sprintf("cardinvoices/%d/reverse", cardInvoiceNo)
-	data, err := c.do(ctx, http.MethodPost, path, query, form)
+	data, err := c.do(ctx, "reverse-card-invoice", http.MethodPost, path, query, form)
 	if err != nil {
 		return nil, err
 	}
@@ -443,19 +515,52 @@ func (c *Client) ReverseCardInvoice(ctx context.Context, cardInvoiceNo int) (*Ca
 	if err := resp.check(); err != nil {
 		return nil, err
 	}
+	c.metrics().CardInvoiceReversed()
+	c.storeStatus(ctx, InvoiceKindCard, cardInvoiceNo, InvoiceStatusPaymentReturned)
 	return &resp, nil
 }
 
 func (c *Client) CreateWebInvoice(ctx context.Context, r AddWebInvoiceRequest) (*AddWebInvoiceResponse, error) {
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	form, err := c.webInvoiceForm(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.do(ctx, "add-web-invoice", http.MethodPost, "web_invoices", nil, form)
+	if err != nil {
+		return nil, err
+	}
+	var resp AddWebInvoiceResponse
+	if err := decodeJSON(data, &resp); err != nil {
+		return nil, err
+	}
+	sigParams := map[string]string{
+		"ExpressPayAccountNumber": resp.ExpressPayAccountNumber,
+		"ExpressPayInvoiceNo":     resp.ExpressPayInvoiceNo.String(),
+	}
+	if err := c.verifyResponseSignature("add-web-invoice-response", sigParams, resp.Signature); err != nil {
+		return nil, err
+	}
+	c.metrics().InvoiceCreated(InvoiceKindERIP)
+	c.storeInvoice(ctx, InvoiceKindERIP, resp.ExpressPayInvoiceNo, r.AccountNo, r.Amount, r)
+	return &resp, nil
+}
+
+func (c *Client) webInvoiceForm(r AddWebInvoiceRequest) (url.Values, error) {
 	form := url.Values{}
 	form.Set("ServiceId", r.ServiceID)
 	form.Set("AccountNo", r.AccountNo)
-	form.Set("Amount", r.Amount)
-	form.Set("Currency", r.Currency)
+	form.Set("Amount", r.Amount.String())
+	form.Set("Currency", r.Amount.Currency)
 	form.Set("ReturnType", r.ReturnType)
 	form.Set("ReturnUrl", r.ReturnURL)
 	form.Set("FailUrl", r.FailURL)
-	addIfNotEmpty(form, "Expiration", r.Expiration)
+	addIfNotEmpty(form, "Expiration", formatDate(r.Expiration))
 	addIfNotEmpty(form, "Info", r.Info)
 	addIfNotEmpty(form, "Surname", r.Surname)
 	addIfNotEmpty(form, "FirstName", r.FirstName)
@@ -476,9 +581,9 @@ func (c *Client) CreateWebInvoice(ctx context.Context, r AddWebInvoiceRequest) (
 		"Token":             c.Token,
 		"ServiceId":         r.ServiceID,
 		"AccountNo":         r.AccountNo,
-		"Amount":            r.Amount,
-		"Currency":          r.Currency,
-		"Expiration":        r.Expiration,
+		"Amount":            r.Amount.String(),
+		"Currency":          r.Amount.Currency,
+		"Expiration":        formatDate(r.Expiration),
 		"Info":              r.Info,
 		"Surname":           r.Surname,
 		"FirstName":         r.FirstName,
@@ -501,63 +606,76 @@ func (c *Client) CreateWebInvoice(ctx context.Context, r AddWebInvoiceRequest) (
 	if err := c.applySignature("add-web-invoice", sigParams, nil, form, false); err != nil {
 		return nil, err
 	}
+	return form, nil
+}
+
+func (c *Client) CreateWebCardInvoice(ctx context.Context, r AddWebCardInvoiceRequest) (*AddWebCardInvoiceResponse, error) {
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	form, err := c.webCardInvoiceForm(r)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := c.do(ctx, http.MethodPost, "web_invoices", nil, form)
+	data, err := c.do(ctx, "add-webcard-invoice", http.MethodPost, "web_cardinvoices", nil, form)
 	if err != nil {
 		return nil, err
 	}
-	var resp AddWebInvoiceResponse
+	var resp AddWebCardInvoiceResponse
 	if err := decodeJSON(data, &resp); err != nil {
 		return nil, err
 	}
+	sigParams := map[string]string{
+		"ExpressPayAccountNumber": resp.ExpressPayAccountNumber,
+		"ExpressPayInvoiceNo":     resp.ExpressPayInvoiceNo.String(),
+	}
+	if err := c.verifyResponseSignature("add-webcard-invoice-response", sigParams, resp.Signature); err != nil {
+		return nil, err
+	}
+	c.metrics().InvoiceCreated(InvoiceKindCard)
+	c.storeInvoice(ctx, InvoiceKindCard, resp.ExpressPayInvoiceNo, r.AccountNo, r.Amount, r)
 	return &resp, nil
 }
 
-func (c *Client) CreateWebCardInvoice(ctx context.Context, r AddWebCardInvoiceRequest) (*AddWebCardInvoiceResponse, error) {
+func (c *Client) webCardInvoiceForm(r AddWebCardInvoiceRequest) (url.Values, error) {
 	form := url.Values{}
 	form.Set("ServiceId", r.ServiceID)
 	form.Set("AccountNo", r.AccountNo)
-	form.Set("Amount", r.Amount)
-	form.Set("Currency", r.Currency)
+	form.Set("Amount", r.Amount.String())
+	form.Set("Currency", r.Amount.Currency)
 	form.Set("Info", r.Info)
 	form.Set("ReturnType", r.ReturnType)
 	form.Set("ReturnUrl", r.ReturnURL)
 	form.Set("FailUrl", r.FailURL)
-	addIfNotEmpty(form, "Expiration", r.Expiration)
+	addIfNotEmpty(form, "Expiration", formatDate(r.Expiration))
 	addIfNotEmpty(form, "Language", r.Language)
 	addIfNotEmpty(form, "SessionTimeoutSecs", r.SessionTimeoutSecs)
-	addIfNotEmpty(form, "ExpirationDate", r.ExpirationDate)
+	addIfNotEmpty(form, "ExpirationDate", formatDateTime(r.ExpirationDate))
 	addIfNotEmpty(form, "ReturnInvoiceUrl", r.ReturnInvoiceURL)
 
 	sigParams := map[string]string{
 		"Token":              c.Token,
 		"ServiceId":          r.ServiceID,
 		"AccountNo":          r.AccountNo,
-		"Expiration":         r.Expiration,
-		"Amount":             r.Amount,
-		"Currency":           r.Currency,
+		"Expiration":         formatDate(r.Expiration),
+		"Amount":             r.Amount.String(),
+		"Currency":           r.Amount.Currency,
 		"Info":               r.Info,
 		"ReturnUrl":          r.ReturnURL,
 		"FailUrl":            r.FailURL,
 		"Language":           r.Language,
 		"SessionTimeoutSecs": r.SessionTimeoutSecs,
-		"ExpirationDate":     r.ExpirationDate,
+		"ExpirationDate":     formatDateTime(r.ExpirationDate),
 		"ReturnType":         r.ReturnType,
 		"ReturnInvoiceUrl":   r.ReturnInvoiceURL,
 	}
 	if err := c.applySignature("add-webcard-invoice", sigParams, nil, form, false); err != nil {
 		return nil, err
 	}
-
-	data, err := c.do(ctx, http.MethodPost, "web_cardinvoices", nil, form)
-	if err != nil {
-		return nil, err
-	}
-	var resp AddWebCardInvoiceResponse
-	if err := decodeJSON(data, &resp); err != nil {
-		return nil, err
-	}
-	return &resp, nil
+	return form, nil
 }
 
 func (c *Client) applySignature(action string, params map[string]string, query url.Values, form url.Values, inQuery bool) error {
@@ -583,45 +701,181 @@ func (c *Client) applySignature(action string, params map[string]string, query u
 	return nil
 }
 
-func (c *Client) do(ctx context.Context, method, path string, query url.Values, form url.Values) ([]byte, error) {
+// do sends the request for action (the signatureOrder key, used for metrics) and returns the
+// body of a successful response.
+func (c *Client) do(ctx context.Context, action, method, path string, query url.Values, form url.Values) ([]byte, error) {
+	var data []byte
+	err := c.exchange(ctx, action, method, path, query, form, func(status int, body io.Reader) error {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return err
+		}
+		if apiErr := parseAPIError(data); apiErr != nil {
+			apiErr.HTTPStatus = status
+			return apiErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// exchange sends the request, retrying according to c.Retry, and hands the body of a
+// successful response to consume. Responses with status >= 400 are turned into errors.
+func (c *Client) exchange(ctx context.Context, action, method, path string, query url.Values, form url.Values, consume func(status int, body io.Reader) error) error {
 	fullURL := c.BaseURL + path
 	if query != nil && len(query) > 0 {
 		fullURL += "?" + query.Encode()
 	}
+	if method != http.MethodPost && method != http.MethodPut && method != http.MethodDelete {
+		form = nil
+	}
 
-	var body io.Reader
-	if method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete {
-		if form != nil {
-			body = strings.NewReader(form.Encode())
+	attempts := c.Retry.attempts()
+	for attempt := 1; ; attempt++ {
+		report, err := c.breaker.allow()
+		if err != nil {
+			return err
+		}
+		release, err := c.limits.acquire(ctx, path)
+		if err != nil {
+			report(attemptIgnored)
+			return err
+		}
+		start := time.Now()
+		resp, sent, err := c.send(ctx, method, fullURL, form)
+		last := attempt >= attempts
+		if err != nil {
+			release()
+			report(attemptResult(ctx, 0, err))
+			elapsed := time.Since(start)
+			c.logExchange(ctx, method, path, query, form, attempt, 0, elapsed, nil, err)
+			c.observeRequest(action, 0, elapsed, err)
+			if last || !c.shouldRetry(ctx, method, sent, 0, err) {
+				return err
+			}
+			if sleepCtx(ctx, c.Retry.backoff(attempt)) != nil {
+				return err
+			}
+			continue
 		}
+		if resp.StatusCode < 400 {
+			// The concurrency slots cover the request up to the response headers. consume may
+			// hand items to user code (InvoicesSeq), which can call the client again; holding
+			// the slots while it runs would deadlock WithMaxConcurrent(1).
+			release()
+			// The headers prove the API is up; a body that breaks off later is reported again
+			// as a failure.
+			report(attemptSucceeded)
+			tracked := &bodyReader{r: resp.Body}
+			var body io.Reader = tracked
+			var logged bytes.Buffer
+			if c.debugEnabled(ctx) {
+				body = io.TeeReader(body, &logged)
+			}
+			err := consume(resp.StatusCode, body)
+			resp.Body.Close()
+			if tracked.err != nil {
+				report(attemptResult(ctx, 0, tracked.err))
+			}
+			elapsed := time.Since(start)
+			c.logExchange(ctx, method, path, query, form, attempt, resp.StatusCode, elapsed, logged.Bytes(), err)
+			c.observeRequest(action, resp.StatusCode, elapsed, err)
+			// A body that broke off is retried like any transport error. consume must have
+			// failed on the read itself: if it stopped for another reason (the caller ended an
+			// InvoicesSeq loop), repeating it would call back into code that is done. Items a
+			// streamed list already yielded are skipped on the second pass by its pageDedup.
+			if tracked.err == nil || err != tracked.err || last || !c.shouldRetry(ctx, method, true, 0, err) {
+				return err
+			}
+			if sleepCtx(ctx, c.Retry.backoff(attempt)) != nil {
+				return err
+			}
+			continue
+		}
+
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		release()
+		report(attemptResult(ctx, resp.StatusCode, readErr))
+		err = statusError(resp.StatusCode, resp.Header, data)
+		if readErr != nil {
+			err = readErr
+		}
+		elapsed := time.Since(start)
+		c.logExchange(ctx, method, path, query, form, attempt, resp.StatusCode, elapsed, data, err)
+		c.observeRequest(action, resp.StatusCode, elapsed, err)
+		if last || !c.shouldRetry(ctx, method, true, resp.StatusCode, readErr) {
+			return err
+		}
+		wait := c.Retry.backoff(attempt)
+		if d, ok := retryAfter(resp.Header); ok {
+			wait = c.Retry.limit(d)
+		}
+		if sleepCtx(ctx, wait) != nil {
+			return err
+		}
+	}
+}
+
+// bodyReader remembers the first error other than io.EOF that reading the body ran into.
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (c *Client) send(ctx context.Context, method, fullURL string, form url.Values) (*http.Response, bool, error) {
+	sent := false
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { sent = true },
 	}
+	ctx = httptrace.WithClientTrace(ctx, trace)
 
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	if form != nil && (method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete) {
+	if form != nil {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, sent, err
 	}
-	defer resp.Body.Close()
+	return resp, true, nil
+}
 
-	data, err := io.ReadAll(resp.Body)
+func (c *Client) shouldRetry(ctx context.Context, method string, sent bool, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
 	if err != nil {
-		return nil, err
+		return isIdempotent(method) || (!sent && isDialError(err))
 	}
+	return isIdempotent(method) && retryableStatus(status)
+}
+
+func statusError(status int, header http.Header, data []byte) error {
 	if apiErr := parseAPIError(data); apiErr != nil {
-		apiErr.HTTPStatus = resp.StatusCode
-		return nil, apiErr
+		apiErr.HTTPStatus = status
+		return apiErr
 	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
-	}
-	return data, nil
+	return &HTTPError{StatusCode: status, Header: header, Body: string(data)}
 }
 
 func decodeJSON(data []byte, v any) error {
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+// parse parses flags that may appear before or after positional arguments.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (a *app) id(fs *flag.FlagSet, args []string) (int, error) {
+	rest, err := parse(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		return 0, fmt.Errorf("%s: expected exactly one number", a.name)
+	}
+	n, err := strconv.Atoi(rest[0])
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid number %q", a.name, rest[0])
+	}
+	return n, nil
+}
+
+func (a *app) noArgs(fs *flag.FlagSet, args []string) error {
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return fmt.Errorf("%s: unexpected arguments %v", a.name, rest)
+	}
+	return nil
+}
+
+type moneyFlag struct {
+	value    string
+	currency string
+}
+
+func (m *moneyFlag) String() string     { return m.value }
+func (m *moneyFlag) Set(v string) error { m.value = v; return nil }
+
+func (m *moneyFlag) money() (expresspay.Money, error) {
+	if m.value == "" {
+		return expresspay.Money{}, fmt.Errorf("--amount is required")
+	}
+	return expresspay.ParseMoney(m.value, m.currency)
+}
+
+type timeFlag struct {
+	t *time.Time
+}
+
+func (f timeFlag) String() string {
+	if f.t == nil || f.t.IsZero() {
+		return ""
+	}
+	return f.t.In(expresspay.Minsk).Format(expresspay.TimestampLayout)
+}
+
+func (f timeFlag) Set(v string) error {
+	t, err := expresspay.ParseTime(v)
+	if err != nil {
+		return err
+	}
+	*f.t = t
+	return nil
+}
+
+func boolParam(v bool) string {
+	if v {
+		return "1"
+	}
+	return ""
+}
+
+func invoiceCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var r expresspay.AddInvoiceRequest
+	var amount moneyFlag
+	var nameEditable, addressEditable, amountEditable, returnURL bool
+	fs.StringVar(&r.AccountNo, "account", "", "account number")
+	fs.Var(&amount, "amount", "amount, e.g. 10,00")
+	fs.StringVar(&amount.currency, "currency", expresspay.CurrencyBYN, "ISO 4217 numeric currency code")
+	fs.Var(timeFlag{&r.Expiration}, "expiration", "expiration date yyyyMMdd")
+	fs.StringVar(&r.Info, "info", "", "invoice description")
+	fs.StringVar(&r.Surname, "surname", "", "payer surname")
+	fs.StringVar(&r.FirstName, "first-name", "", "payer first name")
+	fs.StringVar(&r.Patronymic, "patronymic", "", "payer patronymic")
+	fs.StringVar(&r.City, "city", "", "payer city")
+	fs.StringVar(&r.Street, "street", "", "payer street")
+	fs.StringVar(&r.House, "house", "", "payer house")
+	fs.StringVar(&r.Building, "building", "", "payer building")
+	fs.StringVar(&r.Apartment, "apartment", "", "payer apartment")
+	fs.StringVar(&r.EmailNotification, "email", "", "e-mail for notification")
+	fs.StringVar(&r.SmsPhone, "sms", "", "phone for SMS notification")
+	fs.BoolVar(&nameEditable, "name-editable", false, "allow the payer to edit the name")
+	fs.BoolVar(&addressEditable, "address-editable", false, "allow the payer to edit the address")
+	fs.BoolVar(&amountEditable, "amount-editable", false, "allow the payer to edit the amount")
+	fs.BoolVar(&returnURL, "return-invoice-url", false, "return the invoice URL")
+	if err := a.noArgs(fs, args); err != nil {
+		return err
+	}
+	m, err := amount.money()
+	if err != nil {
+		return err
+	}
+	r.Amount = m
+	r.IsNameEditable = boolParam(nameEditable)
+	r.IsAddressEditable = boolParam(addressEditable)
+	r.IsAmountEditable = boolParam(amountEditable)
+	r.ReturnInvoiceURL = boolParam(returnURL)
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.CreateInvoice(ctx, r)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func invoiceGet(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetInvoice(ctx, no)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func invoiceStatus(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetInvoiceStatus(ctx, no)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func invoiceCancel(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if err := c.CancelInvoice(ctx, no); err != nil {
+		return err
+	}
+	return a.print(map[string]any{"InvoiceNo": no, "Status": expresspay.InvoiceStatusCanceled})
+}
+
+func invoiceList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var p expresspay.ListInvoicesParams
+	var status string
+	fs.Var(timeFlag{&p.From}, "from", "start date yyyyMMdd")
+	fs.Var(timeFlag{&p.To}, "to", "end date yyyyMMdd")
+	fs.StringVar(&p.AccountNo, "account", "", "account number")
+	fs.StringVar(&status, "status", "", "invoice status code")
+	if err := a.noArgs(fs, args); err != nil {
+		return err
+	}
+	p.Status = expresspay.InvoiceStatus(status)
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	items, err := c.ListInvoices(ctx, p)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
+
+func paymentList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var p expresspay.ListPaymentsParams
+	fs.Var(timeFlag{&p.From}, "from", "start date yyyyMMdd")
+	fs.Var(timeFlag{&p.To}, "to", "end date yyyyMMdd")
+	fs.StringVar(&p.AccountNo, "account", "", "account number")
+	if err := a.noArgs(fs, args); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	items, err := c.ListPayments(ctx, p)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
+
+func paymentGet(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetPayment(ctx, no)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func cardCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var r expresspay.AddCardInvoiceRequest
+	var amount moneyFlag
+	var returnURL bool
+	fs.StringVar(&r.AccountNo, "account", "", "account number")
+	fs.Var(&amount, "amount", "amount, e.g. 10,00")
+	fs.StringVar(&amount.currency, "currency", expresspay.CurrencyBYN, "ISO 4217 numeric currency code")
+	fs.Var(timeFlag{&r.Expiration}, "expiration", "expiration date yyyyMMdd")
+	fs.StringVar(&r.Info, "info", "", "invoice description")
+	fs.StringVar(&r.ReturnURL, "return-url", "", "URL to redirect to after payment")
+	fs.StringVar(&r.FailURL, "fail-url", "", "URL to redirect to after a failed payment")
+	fs.StringVar(&r.Language, "language", "", "payment page language")
+	fs.StringVar(&r.SessionTimeoutSecs, "session-timeout", "", "payment session timeout in seconds")
+	fs.Var(timeFlag{&r.ExpirationDate}, "expiration-date", "expiration date and time yyyyMMddHHmm")
+	fs.BoolVar(&returnURL, "return-invoice-url", false, "return the invoice URL")
+	if err := a.noArgs(fs, args); err != nil {
+		return err
+	}
+	m, err := amount.money()
+	if err != nil {
+		return err
+	}
+	r.Amount = m
+	r.ReturnInvoiceURL = boolParam(returnURL)
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.CreateCardInvoice(ctx, r)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func cardStatus(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	language := fs.String("language", "", "response language")
+	no, err := a.id(fs, args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetCardInvoiceStatus(ctx, no, *language)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func cardReverse(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.ReverseCardInvoice(ctx, no)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func cardForm(ctx context.Context, a *app, args []string) error {
+	no, err := a.id(a.flags(), args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetCardInvoicePaymentForm(ctx, no)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func qrGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var p expresspay.QRCodeParams
+	fs.StringVar(&p.ViewType, "view", expresspay.QRCodeViewTypeBase64, "view type: base64 or text")
+	fs.StringVar(&p.ImageWidth, "width", "", "image width in pixels")
+	fs.StringVar(&p.ImageHeight, "height", "", "image height in pixels")
+	no, err := a.id(fs, args)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	resp, err := c.GetQRCode(ctx, no, p)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func webCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var r expresspay.AddWebInvoiceRequest
+	var amount moneyFlag
+	var card, returnURL bool
+	fs.BoolVar(&card, "card", false, "create a web card invoice")
+	fs.StringVar(&r.ServiceID, "service", "", "service ID")
+	fs.StringVar(&r.AccountNo, "account", "", "account number")
+	fs.Var(&amount, "amount", "amount, e.g. 10,00")
+	fs.StringVar(&amount.currency, "currency", expresspay.CurrencyBYN, "ISO 4217 numeric currency code")
+	fs.Var(timeFlag{&r.Expiration}, "expiration", "expiration date yyyyMMdd")
+	fs.StringVar(&r.Info, "info", "", "invoice description")
+	fs.StringVar(&r.ReturnType, "return-type", "json", "json or redirect")
+	fs.StringVar(&r.ReturnURL, "return-url", "", "URL to redirect to after payment")
+	fs.StringVar(&r.FailURL, "fail-url", "", "URL to redirect to after a failed payment")
+	fs.StringVar(&r.EmailNotification, "email", "", "e-mail for notification")
+	fs.StringVar(&r.SmsPhone, "sms", "", "phone for SMS notification")
+	language := fs.String("language", "", "payment page language (card only)")
+	fs.BoolVar(&returnURL, "return-invoice-url", false, "return the invoice URL")
+	if err := a.noArgs(fs, args); err != nil {
+		return err
+	}
+	m, err := amount.money()
+	if err != nil {
+		return err
+	}
+	r.Amount = m
+	r.ReturnInvoiceURL = boolParam(returnURL)
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	if card {
+		resp, err := c.CreateWebCardInvoice(ctx, expresspay.AddWebCardInvoiceRequest{
+			ServiceID:        r.ServiceID,
+			AccountNo:        r.AccountNo,
+			Expiration:       r.Expiration,
+			Amount:           r.Amount,
+			Info:             r.Info,
+			ReturnType:       r.ReturnType,
+			ReturnURL:        r.ReturnURL,
+			FailURL:          r.FailURL,
+			Language:         *language,
+			ReturnInvoiceURL: r.ReturnInvoiceURL,
+		})
+		if err != nil {
+			return err
+		}
+		return a.print(resp)
+	}
+	resp, err := c.CreateWebInvoice(ctx, r)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
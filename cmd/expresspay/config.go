@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dizel-by/expresspay"
+)
+
+type config struct {
+	Token   string `json:"token"`
+	Secret  string `json:"secret"`
+	BaseURL string `json:"base_url"`
+	Sandbox bool   `json:"sandbox"`
+}
+
+type app struct {
+	name   string
+	usage  string
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	baseURL    string
+	sandbox    bool
+	output     string
+}
+
+func defaultConfigPath() string {
+	if p := os.Getenv("EXPRESSPAY_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "expresspay.json"
+	}
+	return filepath.Join(dir, "expresspay", "config.json")
+}
+
+// tildePath abbreviates a path under home to ~ for display.
+func tildePath(path, home string) string {
+	home = strings.TrimSuffix(home, string(filepath.Separator))
+	if home == "" {
+		return path
+	}
+	if path == home || strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.configPath, "config", defaultConfigPath(), "config file")
+	fs.StringVar(&a.baseURL, "base-url", "", "API base URL")
+	fs.BoolVar(&a.sandbox, "sandbox", false, "use the sandbox API")
+	fs.StringVar(&a.output, "output", "table", "output format: json or table")
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: expresspay %s %s\n", a.name, a.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func loadConfig(path string) (config, error) {
+	var cfg config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (a *app) client() (*expresspay.Client, error) {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sandbox && cfg.BaseURL == "" {
+		cfg.BaseURL = expresspay.SandboxBaseURL
+	}
+	if v := os.Getenv("EXPRESSPAY_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("EXPRESSPAY_SECRET"); v != "" {
+		cfg.Secret = v
+	}
+	if v := os.Getenv("EXPRESSPAY_BASE_URL"); v != "" {
+		cfg.BaseURL = v
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("token is not configured: set EXPRESSPAY_TOKEN or add it to " + a.configPath)
+	}
+	baseURL := cfg.BaseURL
+	if a.sandbox {
+		baseURL = expresspay.SandboxBaseURL
+	}
+	if a.baseURL != "" {
+		baseURL = a.baseURL
+	}
+	return expresspay.NewClient(baseURL, cfg.Token, cfg.Secret), nil
+}
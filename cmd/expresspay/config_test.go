@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestTildePath(t *testing.T) {
+	tests := []struct {
+		path, home, want string
+	}{
+		{"/home/ann/.config/expresspay/config.json", "/home/ann", "~/.config/expresspay/config.json"},
+		{"/home/ann/.config/expresspay/config.json", "/home/ann/", "~/.config/expresspay/config.json"},
+		{"/home/anna/config.json", "/home/ann", "/home/anna/config.json"},
+		{"/etc/expresspay/config.json", "/home/ann", "/etc/expresspay/config.json"},
+		{"/etc/expresspay/config.json", "", "/etc/expresspay/config.json"},
+		{"expresspay.json", "", "expresspay.json"},
+	}
+	for _, tt := range tests {
+		if got := tildePath(tt.path, tt.home); got != tt.want {
+			t.Errorf("tildePath(%q, %q) = %q, want %q", tt.path, tt.home, got, tt.want)
+		}
+	}
+}
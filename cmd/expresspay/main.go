@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"invoice": {
+		"create": {"--account NO --amount 10,00 [--currency 933] [--info TEXT] ...", invoiceCreate},
+		"get":    {"INVOICE_NO", invoiceGet},
+		"status": {"INVOICE_NO", invoiceStatus},
+		"cancel": {"INVOICE_NO", invoiceCancel},
+		"list":   {"[--from yyyyMMdd] [--to yyyyMMdd] [--account NO] [--status CODE]", invoiceList},
+	},
+	"payment": {
+		"list": {"[--from yyyyMMdd] [--to yyyyMMdd] [--account NO]", paymentList},
+		"get":  {"PAYMENT_NO", paymentGet},
+	},
+	"card": {
+		"create":  {"--account NO --amount 10,00 --return-url URL --fail-url URL [--info TEXT] ...", cardCreate},
+		"status":  {"CARD_INVOICE_NO [--language ru]", cardStatus},
+		"reverse": {"CARD_INVOICE_NO", cardReverse},
+		"form":    {"CARD_INVOICE_NO", cardForm},
+	},
+	"qr": {
+		"get": {"INVOICE_NO [--view base64|text] [--width PX] [--height PX]", qrGet},
+	},
+	"web": {
+		"create": {"--service ID --account NO --amount 10,00 --return-url URL --fail-url URL [--card] ...", webCreate},
+	},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "expresspay:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) < 2 {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf("unknown command %q", args[0]+" "+args[1])
+	}
+	a := &app{name: args[0] + " " + args[1], usage: cmd.usage, stdout: stdout, stderr: stderr}
+	return cmd.run(ctx, a, args[2:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: expresspay <command> <subcommand> [flags]")
+	fmt.Fprintln(w)
+	groups := make([]string, 0, len(commands))
+	for name := range commands {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		subs := make([]string, 0, len(commands[g]))
+		for name := range commands[g] {
+			subs = append(subs, name)
+		}
+		sort.Strings(subs)
+		for _, s := range subs {
+			fmt.Fprintf(w, "  %-16s %s\n", g+" "+s, commands[g][s].usage)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Common flags: --sandbox, --base-url URL, --config FILE, --output json|table")
+	fmt.Fprintln(w, "Credentials: EXPRESSPAY_TOKEN, EXPRESSPAY_SECRET, EXPRESSPAY_BASE_URL or the config file")
+	fmt.Fprintln(w, "(default "+tildePath(defaultConfigPath(), os.Getenv("HOME"))+").")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func (a *app) print(v any) error {
+	switch a.output {
+	case "json":
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table", "":
+		return a.printTable(v)
+	}
+	return fmt.Errorf("unknown output format %q", a.output)
+}
+
+func (a *app) printTable(v any) error {
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			fmt.Fprintln(w, "(no items)")
+			break
+		}
+		names := columns(rv.Index(0))
+		fmt.Fprintln(w, strings.Join(names, "\t"))
+		for i := 0; i < rv.Len(); i++ {
+			row := make([]string, len(names))
+			for j, name := range names {
+				row[j] = cell(field(rv.Index(i), name))
+			}
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+	case reflect.Struct, reflect.Map:
+		for _, name := range columns(rv) {
+			fmt.Fprintf(w, "%s\t%s\n", name, cell(field(rv, name)))
+		}
+	default:
+		fmt.Fprintln(w, cell(rv))
+	}
+	return w.Flush()
+}
+
+func columns(v reflect.Value) []string {
+	v = reflect.Indirect(v)
+	var names []string
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() && f.Tag.Get("json") != "-" {
+				names = append(names, f.Name)
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			names = append(names, fmt.Sprint(k.Interface()))
+		}
+		sort.Strings(names)
+	}
+	return names
+}
+
+func field(v reflect.Value, name string) reflect.Value {
+	v = reflect.Indirect(v)
+	if v.Kind() == reflect.Map {
+		return v.MapIndex(reflect.ValueOf(name))
+	}
+	return v.FieldByName(name)
+}
+
+func cell(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,156 @@
+package expresspay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Failed requests are classified by HTTP status. Card endpoints answer errors with status 200,
+// so the Express Pay error code is mapped as well; see errorCodes.
+var (
+	ErrInvalidRequest     = errors.New("expresspay: invalid request")
+	ErrUnauthorized       = errors.New("expresspay: token or signature rejected")
+	ErrNotFound           = errors.New("expresspay: not found")
+	ErrTooManyRequests    = errors.New("expresspay: too many requests")
+	ErrServiceUnavailable = errors.New("expresspay: service unavailable")
+	ErrServerError        = errors.New("expresspay: server error")
+
+	ErrInvalidToken       = errors.New("expresspay: invalid token")
+	ErrInvalidSignature   = errors.New("expresspay: invalid signature")
+	ErrInvoiceNotFound    = errors.New("expresspay: invoice not found")
+	ErrInvoiceAlreadyPaid = errors.New("expresspay: invoice already paid")
+	ErrInvalidAmount      = errors.New("expresspay: invalid amount")
+
+	ErrResponseSignatureMismatch = errors.New("expresspay: response signature mismatch")
+)
+
+// Express Pay error codes, as returned by APIError.APICode.
+const (
+	CodeInvalidToken       = 1
+	CodeInvalidSignature   = 2
+	CodeInvalidRequest     = 3
+	CodeInvoiceNotFound    = 4
+	CodeInvalidStatus      = 5
+	CodeInvoiceAlreadyPaid = 6
+	CodeInvalidAmount      = 7
+	CodePaymentNotFound    = 8
+)
+
+// errorCodes gives the sentinels an error code matches: its own, if any, and the one for the
+// HTTP status the API normally sends it with.
+var errorCodes = map[int][]error{
+	CodeInvalidToken:       {ErrInvalidToken, ErrUnauthorized},
+	CodeInvalidSignature:   {ErrInvalidSignature, ErrUnauthorized},
+	CodeInvalidRequest:     {ErrInvalidRequest},
+	CodeInvoiceNotFound:    {ErrInvoiceNotFound, ErrNotFound},
+	CodeInvalidStatus:      {ErrInvalidRequest},
+	CodeInvoiceAlreadyPaid: {ErrInvoiceAlreadyPaid, ErrInvalidRequest},
+	CodeInvalidAmount:      {ErrInvalidAmount, ErrInvalidRequest},
+	CodePaymentNotFound:    {ErrNotFound},
+}
+
+// APICode returns the Express Pay error code regardless of the envelope it arrived in.
+func (e *APIError) APICode() int {
+	switch {
+	case e.Code != 0:
+		return e.Code
+	case e.ErrorCode != 0:
+		return e.ErrorCode
+	}
+	return e.MsgCode
+}
+
+// Unwrap returns the sentinel for the HTTP status, so errors.Is(err, ErrNotFound) works.
+func (e *APIError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return httpStatusSentinel(e.HTTPStatus)
+}
+
+// Is matches the sentinels for the error code, which also classifies the errors that card
+// endpoints report with status 200.
+func (e *APIError) Is(target error) bool {
+	if e == nil {
+		return false
+	}
+	for _, err := range errorCodes[e.APICode()] {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPError is returned for failed responses whose body is not an Express Pay error.
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	body := strings.TrimSpace(e.Body)
+	if body == "" {
+		return fmt.Sprintf("expresspay: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("expresspay: unexpected status %d: %s", e.StatusCode, body)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return httpStatusSentinel(e.StatusCode)
+}
+
+func httpStatusSentinel(status int) error {
+	switch {
+	case status == http.StatusBadRequest:
+		return ErrInvalidRequest
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case status == http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
+	case status >= 500:
+		return ErrServerError
+	}
+	return nil
+}
+
+// IsRetryable reports whether repeating the request may succeed: throttling, server-side
+// failures, timeouts and failures to connect. Other network errors, such as a rejected TLS
+// certificate, and context cancellation are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrTooManyRequests) || errors.Is(err, ErrServiceUnavailable) || errors.Is(err, ErrServerError) {
+		return true
+	}
+	return isTemporaryNetError(err)
+}
+
+func isTemporaryNetError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// IsAuth reports whether err means the token or signature was rejected (HTTP 401 or 403, or the
+// matching error code).
+func IsAuth(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
@@ -0,0 +1,157 @@
+package expresspay_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+)
+
+func TestStatusSentinels(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusBadRequest, `{"Error": {"Code": 3, "Msg": "bad"}}`, expresspay.ErrInvalidRequest},
+		{http.StatusUnauthorized, `{"Error": {"Code": 1, "Msg": "token"}}`, expresspay.ErrUnauthorized},
+		{http.StatusForbidden, `{"Error": {"Code": 2, "Msg": "signature"}}`, expresspay.ErrUnauthorized},
+		{http.StatusForbidden, `<html>forbidden</html>`, expresspay.ErrUnauthorized},
+		{http.StatusNotFound, `{"Error": {"Code": 4, "Msg": "no invoice"}}`, expresspay.ErrNotFound},
+		{http.StatusTooManyRequests, ``, expresspay.ErrTooManyRequests},
+		{http.StatusServiceUnavailable, ``, expresspay.ErrServiceUnavailable},
+		{http.StatusBadGateway, `bad gateway`, expresspay.ErrServerError},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			c := expresspay.NewClient(srv.URL, "token", "")
+			_, err := c.GetInvoiceStatus(context.Background(), 1)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if got := expresspay.IsAuth(err); got != (tt.want == expresspay.ErrUnauthorized) {
+				t.Errorf("IsAuth = %v", got)
+			}
+		})
+	}
+}
+
+func TestAPIErrorCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ErrorCode": 12, "ErrorMessage": "card declined"}`))
+	}))
+	defer srv.Close()
+	c := expresspay.NewClient(srv.URL, "token", "")
+	_, err := c.GetCardInvoiceStatus(context.Background(), 1, "")
+	var apiErr *expresspay.APIError
+	if !errors.As(err, &apiErr) || apiErr.APICode() != 12 || err.Error() != "card declined" {
+		t.Fatalf("err = %v", err)
+	}
+	if expresspay.IsRetryable(err) || expresspay.IsAuth(err) {
+		t.Error("an unknown error code must not classify the error")
+	}
+}
+
+func TestAPIErrorCodeSentinels(t *testing.T) {
+	tests := []struct {
+		err  *expresspay.APIError
+		want []error
+	}{
+		{&expresspay.APIError{ErrorCode: expresspay.CodeInvalidToken, HTTPStatus: http.StatusOK}, []error{expresspay.ErrInvalidToken, expresspay.ErrUnauthorized}},
+		{&expresspay.APIError{ErrorCode: expresspay.CodeInvalidSignature, HTTPStatus: http.StatusOK}, []error{expresspay.ErrInvalidSignature, expresspay.ErrUnauthorized}},
+		{&expresspay.APIError{ErrorCode: expresspay.CodeInvoiceNotFound, HTTPStatus: http.StatusOK}, []error{expresspay.ErrInvoiceNotFound, expresspay.ErrNotFound}},
+		{&expresspay.APIError{Code: expresspay.CodeInvoiceNotFound, HTTPStatus: http.StatusNotFound}, []error{expresspay.ErrInvoiceNotFound, expresspay.ErrNotFound}},
+		{&expresspay.APIError{ErrorCode: expresspay.CodeInvoiceAlreadyPaid, HTTPStatus: http.StatusOK}, []error{expresspay.ErrInvoiceAlreadyPaid, expresspay.ErrInvalidRequest}},
+		{&expresspay.APIError{MsgCode: expresspay.CodeInvalidAmount, HTTPStatus: http.StatusBadRequest}, []error{expresspay.ErrInvalidAmount, expresspay.ErrInvalidRequest}},
+		{&expresspay.APIError{ErrorCode: expresspay.CodePaymentNotFound, HTTPStatus: http.StatusOK}, []error{expresspay.ErrNotFound}},
+	}
+	all := []error{
+		expresspay.ErrInvalidRequest, expresspay.ErrUnauthorized, expresspay.ErrNotFound, expresspay.ErrServerError,
+		expresspay.ErrInvalidToken, expresspay.ErrInvalidSignature, expresspay.ErrInvoiceNotFound,
+		expresspay.ErrInvoiceAlreadyPaid, expresspay.ErrInvalidAmount,
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("wrapped: %w", tt.err)
+		for _, target := range all {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == target
+			}
+			if got := errors.Is(err, target); got != want {
+				t.Errorf("code %d: errors.Is(%v) = %v", tt.err.APICode(), target, got)
+			}
+		}
+	}
+}
+
+// Card endpoints report errors with status 200; the code still classifies them.
+func TestCardErrorCodeClassified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"ErrorCode": %d, "ErrorMessage": "invalid token"}`, expresspay.CodeInvalidToken)
+	}))
+	defer srv.Close()
+	c := expresspay.NewClient(srv.URL, "token", "")
+	_, err := c.ReverseCardInvoice(context.Background(), 1)
+	if !errors.Is(err, expresspay.ErrInvalidToken) || !expresspay.IsAuth(err) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	urlErr := func(err error) error { return &url.Error{Op: "Get", URL: "https://api.example/", Err: err} }
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", urlErr(context.Canceled), false},
+		{"deadline", urlErr(context.DeadlineExceeded), false},
+		{"throttled", fmt.Errorf("wrapped: %w", expresspay.ErrTooManyRequests), true},
+		{"server error", expresspay.ErrServerError, true},
+		{"connection refused", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		{"read timeout", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}), true},
+		{"temporary DNS failure", urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}}), true},
+		{"unknown host", urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}), false},
+		{"connection reset", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}), false},
+		{"untrusted certificate", urlErr(x509.UnknownAuthorityError{}), false},
+		{"not found", &expresspay.APIError{Code: 4, HTTPStatus: http.StatusNotFound}, false},
+	}
+	for _, tt := range tests {
+		if got := expresspay.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetryableTLSFailure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := expresspay.NewClient(srv.URL, "token", "")
+	_, err := c.GetInvoiceStatus(context.Background(), 1)
+	if err == nil {
+		t.Fatal("untrusted certificate accepted")
+	}
+	if expresspay.IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = true", err)
+	}
+}
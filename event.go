@@ -0,0 +1,90 @@
+package expresspay
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// EventType names a change in invoice or payment state, whether it was reported by a
+// notification (NotificationHandler) or found by polling (Watcher).
+type EventType string
+
+const (
+	EventInvoicePaid     EventType = "InvoicePaid"
+	EventInvoiceExpired  EventType = "InvoiceExpired"
+	EventPaymentReceived EventType = "PaymentReceived"
+)
+
+// Event carries the fields common to both sources. Exactly one of Notification, Invoice and
+// Payment is set, pointing at the record the event was derived from.
+type Event struct {
+	Type          EventType
+	InvoiceNo     json.Number
+	CardInvoiceNo json.Number
+	PaymentNo     json.Number
+	AccountNo     string
+	Status        InvoiceStatus
+	Amount        Money
+	Created       Time
+
+	Notification *Notification
+	Invoice      *Invoice
+	Payment      *Payment
+}
+
+type EventFunc func(ctx context.Context, e Event) error
+
+// Event converts a notification into the matching event. Cancellations and status changes
+// other than paid or expired have no event.
+func (n *Notification) Event() (Event, bool) {
+	e := Event{
+		InvoiceNo:     n.InvoiceNo,
+		CardInvoiceNo: n.CardInvoiceNo,
+		PaymentNo:     n.PaymentNo,
+		AccountNo:     n.AccountNo,
+		Status:        n.Status,
+		Amount:        n.Amount,
+		Created:       n.Created,
+		Notification:  n,
+	}
+	switch n.Type() {
+	case NotificationNewPayment, NotificationCardPayment:
+		e.Type = EventPaymentReceived
+	case NotificationStatusChanged:
+		switch {
+		case n.Status.IsPaid():
+			e.Type = EventInvoicePaid
+		case n.Status == InvoiceStatusExpired:
+			e.Type = EventInvoiceExpired
+		default:
+			return Event{}, false
+		}
+	default:
+		return Event{}, false
+	}
+	return e, true
+}
+
+func invoiceEvent(t EventType, inv *Invoice) Event {
+	return Event{
+		Type:          t,
+		InvoiceNo:     inv.InvoiceNo,
+		CardInvoiceNo: inv.CardInvoiceNo,
+		AccountNo:     inv.AccountNo,
+		Status:        inv.Status,
+		Amount:        inv.Amount,
+		Created:       inv.Created,
+		Invoice:       inv,
+	}
+}
+
+func paymentEvent(p *Payment) Event {
+	return Event{
+		Type:      EventPaymentReceived,
+		PaymentNo: p.PaymentNo,
+		AccountNo: p.AccountNo,
+		Amount:    p.Amount,
+		Created:   p.Created,
+		Payment:   p,
+	}
+}
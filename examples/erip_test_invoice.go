@@ -18,9 +18,8 @@ func main() {
 
 	resp, err := client.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{
 		AccountNo:         "1",
-		Amount:            "0,01",
-		Currency:          "933",
-		Expiration:        time.Now().AddDate(0, 0, 1).Format("20060102"),
+		Amount:            expresspay.BYN(1),
+		Expiration:        time.Now().AddDate(0, 0, 1),
 		Info:              "Test ERIP invoice",
 		IsNameEditable:    "0",
 		IsAddressEditable: "0",
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// cp1251 maps the non-ASCII characters of Windows-1251 to their byte values.
+var cp1251 = func() map[rune]byte {
+	m := map[rune]byte{
+		'Ђ': 0x80, 'Ѓ': 0x81, '‚': 0x82, 'ѓ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+		'€': 0x88, '‰': 0x89, 'Љ': 0x8A, '‹': 0x8B, 'Њ': 0x8C, 'Ќ': 0x8D, 'Ћ': 0x8E, 'Џ': 0x8F,
+		'ђ': 0x90, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+		'™': 0x99, 'љ': 0x9A, '›': 0x9B, 'њ': 0x9C, 'ќ': 0x9D, 'ћ': 0x9E, 'џ': 0x9F,
+		'\u00A0': 0xA0, 'Ў': 0xA1, 'ў': 0xA2, 'Ј': 0xA3, '¤': 0xA4, 'Ґ': 0xA5, '¦': 0xA6, '§': 0xA7,
+		'Ё': 0xA8, '©': 0xA9, 'Є': 0xAA, '«': 0xAB, '¬': 0xAC, '\u00AD': 0xAD, '®': 0xAE, 'Ї': 0xAF,
+		'°': 0xB0, '±': 0xB1, 'І': 0xB2, 'і': 0xB3, 'ґ': 0xB4, 'µ': 0xB5, '¶': 0xB6, '·': 0xB7,
+		'ё': 0xB8, '№': 0xB9, 'є': 0xBA, '»': 0xBB, 'ј': 0xBC, 'Ѕ': 0xBD, 'ѕ': 0xBE, 'ї': 0xBF,
+	}
+	for r := 'А'; r <= 'я'; r++ {
+		m[r] = byte(0xC0 + (r - 'А'))
+	}
+	return m
+}()
+
+// cp1251Writer transcodes UTF-8 to Windows-1251. A rune split across Write calls is held back
+// until the rest arrives; Close writes one that never completes as '?'.
+type cp1251Writer struct {
+	w       io.Writer
+	pending []byte
+}
+
+func (e *cp1251Writer) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(e.pending) > 0 {
+		p = append(e.pending, p...)
+		e.pending = nil
+	}
+	out := make([]byte, 0, len(p))
+	for len(p) > 0 {
+		if p[0] < utf8.RuneSelf {
+			out = append(out, p[0])
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			e.pending = append([]byte(nil), p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+		if b, ok := cp1251[r]; ok {
+			out = append(out, b)
+		} else {
+			out = append(out, '?')
+		}
+	}
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (e *cp1251Writer) Close() error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	e.pending = nil
+	_, err := e.w.Write([]byte{'?'})
+	return err
+}
@@ -0,0 +1,201 @@
+// Package export writes invoices and payments as CSV or JSON Lines for spreadsheets and
+// accounting software. Columns are fixed per record type and always come in the same order.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+type Record interface {
+	expresspay.Invoice | expresspay.Payment | expresspay.PaymentDetails
+}
+
+// TimeLayout is used for Created and Expiration, in the Europe/Minsk zone.
+const TimeLayout = "2006-01-02 15:04:05"
+
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' when zero. Spreadsheets in locales with a decimal comma
+	// usually expect ';'.
+	Comma rune
+	// DecimalComma writes amounts as "10,50" instead of "10.50".
+	DecimalComma bool
+	// Windows1251 encodes the output in Windows-1251 instead of UTF-8. Characters outside the
+	// code page are written as '?'.
+	Windows1251 bool
+	NoHeader    bool
+}
+
+type column[T any] struct {
+	name    string
+	numeric bool
+	value   func(r *T, decimalComma bool) string
+}
+
+func text[T any](name string, fn func(r *T) string) column[T] {
+	return column[T]{name: name, value: func(r *T, _ bool) string { return fn(r) }}
+}
+
+func number[T any](name string, fn func(r *T) json.Number) column[T] {
+	return column[T]{name: name, numeric: true, value: func(r *T, _ bool) string { return fn(r).String() }}
+}
+
+func amount[T any](name string, fn func(r *T) expresspay.Money) column[T] {
+	return column[T]{name: name, numeric: true, value: func(r *T, decimalComma bool) string {
+		if decimalComma {
+			return fn(r).String()
+		}
+		return fn(r).Decimal()
+	}}
+}
+
+func timestamp[T any](name string, fn func(r *T) expresspay.Time) column[T] {
+	return text(name, func(r *T) string { return formatTime(fn(r).Time) })
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(expresspay.Minsk).Format(TimeLayout)
+}
+
+var invoiceColumns = []column[expresspay.Invoice]{
+	number("InvoiceNo", func(r *expresspay.Invoice) json.Number { return r.InvoiceNo }),
+	number("CardInvoiceNo", func(r *expresspay.Invoice) json.Number { return r.CardInvoiceNo }),
+	text("AccountNo", func(r *expresspay.Invoice) string { return r.AccountNo }),
+	text("Status", func(r *expresspay.Invoice) string { return r.Status.String() }),
+	text("StatusCode", func(r *expresspay.Invoice) string { return string(r.Status) }),
+	timestamp("Created", func(r *expresspay.Invoice) expresspay.Time { return r.Created }),
+	timestamp("Expiration", func(r *expresspay.Invoice) expresspay.Time { return r.Expiration }),
+	amount("Amount", func(r *expresspay.Invoice) expresspay.Money { return r.Amount }),
+	text("Currency", func(r *expresspay.Invoice) string { return expresspay.CurrencyName(r.Currency.String()) }),
+}
+
+var paymentColumns = []column[expresspay.Payment]{
+	number("PaymentNo", func(r *expresspay.Payment) json.Number { return r.PaymentNo }),
+	text("AccountNo", func(r *expresspay.Payment) string { return r.AccountNo }),
+	timestamp("Created", func(r *expresspay.Payment) expresspay.Time { return r.Created }),
+	amount("Amount", func(r *expresspay.Payment) expresspay.Money { return r.Amount }),
+	text("Currency", func(r *expresspay.Payment) string { return expresspay.CurrencyName(r.Currency.String()) }),
+	text("Info", func(r *expresspay.Payment) string { return r.Info }),
+	text("Surname", func(r *expresspay.Payment) string { return r.Surname }),
+	text("FirstName", func(r *expresspay.Payment) string { return r.FirstName }),
+	text("Patronymic", func(r *expresspay.Payment) string { return r.Patronymic }),
+	text("City", func(r *expresspay.Payment) string { return r.City }),
+	text("Street", func(r *expresspay.Payment) string { return r.Street }),
+	text("House", func(r *expresspay.Payment) string { return r.House }),
+	text("Building", func(r *expresspay.Payment) string { return r.Building }),
+	text("Apartment", func(r *expresspay.Payment) string { return r.Apartment }),
+}
+
+var paymentDetailsColumns = []column[expresspay.PaymentDetails]{
+	text("AccountNo", func(r *expresspay.PaymentDetails) string { return r.AccountNo }),
+	timestamp("Created", func(r *expresspay.PaymentDetails) expresspay.Time { return r.Created }),
+	amount("Amount", func(r *expresspay.PaymentDetails) expresspay.Money { return r.Amount }),
+	text("Currency", func(r *expresspay.PaymentDetails) string { return expresspay.CurrencyName(r.Currency.String()) }),
+	text("Info", func(r *expresspay.PaymentDetails) string { return r.Info }),
+	text("Surname", func(r *expresspay.PaymentDetails) string { return r.Surname }),
+	text("FirstName", func(r *expresspay.PaymentDetails) string { return r.FirstName }),
+	text("Patronymic", func(r *expresspay.PaymentDetails) string { return r.Patronymic }),
+	text("City", func(r *expresspay.PaymentDetails) string { return r.City }),
+	text("Street", func(r *expresspay.PaymentDetails) string { return r.Street }),
+	text("House", func(r *expresspay.PaymentDetails) string { return r.House }),
+	text("Building", func(r *expresspay.PaymentDetails) string { return r.Building }),
+	text("Apartment", func(r *expresspay.PaymentDetails) string { return r.Apartment }),
+}
+
+// rows returns the header and a row function for T, hiding the per-type column tables behind
+// a uniform signature.
+func rows[T Record](records []T) (header []string, numeric []bool, row func(i int, decimalComma bool) []string) {
+	switch rs := any(records).(type) {
+	case []expresspay.Invoice:
+		return table(invoiceColumns, rs)
+	case []expresspay.Payment:
+		return table(paymentColumns, rs)
+	case []expresspay.PaymentDetails:
+		return table(paymentDetailsColumns, rs)
+	}
+	panic("export: unsupported record type")
+}
+
+func table[T any](cols []column[T], records []T) ([]string, []bool, func(int, bool) []string) {
+	header := make([]string, len(cols))
+	numeric := make([]bool, len(cols))
+	for i, c := range cols {
+		header[i], numeric[i] = c.name, c.numeric
+	}
+	return header, numeric, func(i int, decimalComma bool) []string {
+		out := make([]string, len(cols))
+		for j, c := range cols {
+			out[j] = c.value(&records[i], decimalComma)
+		}
+		return out
+	}
+}
+
+func CSV[T Record](w io.Writer, records []T, opts CSVOptions) error {
+	var enc *cp1251Writer
+	if opts.Windows1251 {
+		enc = &cp1251Writer{w: w}
+		w = enc
+	}
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	header, _, row := rows(records)
+	if !opts.NoHeader {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for i := range records {
+		if err := cw.Write(row(i, opts.DecimalComma)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if enc != nil {
+		return enc.Close()
+	}
+	return nil
+}
+
+// JSONLines writes one JSON object per record with keys in column order. Amounts and numbers are
+// JSON numbers with a dot separator; everything else is a string.
+func JSONLines[T Record](w io.Writer, records []T) error {
+	bw := bufio.NewWriter(w)
+	header, numeric, row := rows(records)
+	for i := range records {
+		values := row(i, false)
+		bw.WriteByte('{')
+		for j, v := range values {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			key, _ := json.Marshal(header[j])
+			bw.Write(key)
+			bw.WriteByte(':')
+			switch {
+			case numeric[j] && v == "":
+				bw.WriteString("null")
+			case numeric[j]:
+				bw.WriteString(v)
+			default:
+				s, _ := json.Marshal(v)
+				bw.Write(s)
+			}
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
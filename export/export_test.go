@@ -0,0 +1,117 @@
+package export_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/export"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, byte for byte.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\ngot  %q\nwant %q", name, got, want)
+	}
+}
+
+func minsk(day, hour, min int) expresspay.Time {
+	return expresspay.Time{Time: time.Date(2024, 3, day, hour, min, 0, 0, expresspay.Minsk)}
+}
+
+func invoices() []expresspay.Invoice {
+	return []expresspay.Invoice{
+		{
+			InvoiceNo:  "101",
+			AccountNo:  "Заказ-1",
+			Status:     expresspay.InvoiceStatusPaid,
+			Created:    minsk(1, 10, 30),
+			Expiration: minsk(8, 0, 0),
+			Amount:     expresspay.BYN(105050),
+			Currency:   expresspay.CurrencyBYN,
+		},
+		{
+			InvoiceNo:     "102",
+			CardInvoiceNo: "102",
+			AccountNo:     "order, \"2\"",
+			Status:        expresspay.InvoiceStatusPendingPayment,
+			// UTC input is written in Minsk time.
+			Created:  expresspay.Time{Time: time.Date(2024, 3, 1, 21, 15, 0, 0, time.UTC)},
+			Amount:   expresspay.NewMoney(7, expresspay.CurrencyUSD),
+			Currency: expresspay.CurrencyUSD,
+		},
+	}
+}
+
+func payments() []expresspay.Payment {
+	return []expresspay.Payment{
+		{
+			PaymentNo: "7",
+			AccountNo: "Заказ-1",
+			Created:   minsk(2, 9, 5),
+			Amount:    expresspay.BYN(105050),
+			Currency:  expresspay.CurrencyBYN,
+			Info:      "Оплата № 1 — «ЎЁё» €",
+			Surname:   "Łukaszewicz Ω",
+			FirstName: "Іван 😀",
+			City:      "Мінск",
+		},
+	}
+}
+
+func TestCSVInvoices(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.CSV(&buf, invoices(), export.CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoices.csv", buf.Bytes())
+}
+
+func TestCSVInvoicesSpreadsheet(t *testing.T) {
+	var buf bytes.Buffer
+	opts := export.CSVOptions{Comma: ';', DecimalComma: true, Windows1251: true}
+	if err := export.CSV(&buf, invoices(), opts); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "invoices-cp1251.csv", buf.Bytes())
+}
+
+func TestCSVPaymentsWindows1251(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.CSV(&buf, payments(), export.CSVOptions{Windows1251: true, NoHeader: true}); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "payments-cp1251.csv", buf.Bytes())
+	// Characters outside the code page become '?', one per character.
+	if !bytes.Contains(buf.Bytes(), []byte("?ukaszewicz ?,\xb2\xe2\xe0\xed ?,")) {
+		t.Errorf("unencodable characters not replaced: %q", buf.Bytes())
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.JSONLines(&buf, invoices()); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.JSONLines(&buf, payments()); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "records.jsonl", buf.Bytes())
+}
@@ -0,0 +1,833 @@
+package expresspaytest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+// Error codes reported by the fake server, from the expresspay catalogue.
+const (
+	CodeInvalidToken       = expresspay.CodeInvalidToken
+	CodeInvalidSignature   = expresspay.CodeInvalidSignature
+	CodeInvalidRequest     = expresspay.CodeInvalidRequest
+	CodeInvoiceNotFound    = expresspay.CodeInvoiceNotFound
+	CodeInvalidStatus      = expresspay.CodeInvalidStatus
+	CodeInvoiceAlreadyPaid = expresspay.CodeInvoiceAlreadyPaid
+	CodeAmountInvalid      = expresspay.CodeInvalidAmount
+	CodePaymentNotFound    = expresspay.CodePaymentNotFound
+)
+
+type Server struct {
+	*httptest.Server
+
+	Token  string
+	Secret string
+	// ServiceID, if set, is the only ServiceId accepted by the web invoice endpoints.
+	ServiceID string
+	Now       func() time.Time
+
+	mu            sync.Mutex
+	invoices      map[int]*invoice
+	payments      map[int]*payment
+	nextInvoiceNo int
+	nextPaymentNo int
+	failures      map[string][]expresspay.APIError
+}
+
+type invoice struct {
+	no         int
+	card       bool
+	web        bool
+	request    expresspay.AddInvoiceRequest
+	status     expresspay.InvoiceStatus
+	created    time.Time
+	paid       expresspay.Money
+	returnURL  string
+	failURL    string
+	serviceID  string
+	paymentNos []int
+}
+
+type payment struct {
+	no        int
+	invoiceNo int
+	amount    expresspay.Money
+	created   time.Time
+}
+
+func NewServer(token, secret string) *Server {
+	s := &Server{
+		Token:         token,
+		Secret:        secret,
+		Now:           time.Now,
+		invoices:      map[int]*invoice{},
+		payments:      map[int]*payment{},
+		nextInvoiceNo: 1,
+		nextPaymentNo: 1,
+		failures:      map[string][]expresspay.APIError{},
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+func (s *Server) BaseURL() string {
+	return s.Server.URL + "/"
+}
+
+func (s *Server) Client(opts ...expresspay.Option) *expresspay.Client {
+	opts = append([]expresspay.Option{expresspay.WithHTTPClient(s.Server.Client())}, opts...)
+	return expresspay.NewClient(s.BaseURL(), s.Token, s.Secret, opts...)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /invoices", s.handle("get-list-invoices", "", s.listInvoices))
+	mux.HandleFunc("POST /invoices", s.handle("add-invoice", "", s.addInvoice))
+	mux.HandleFunc("GET /invoices/{id}", s.handle("get-details-invoice", "Id", s.invoiceDetails))
+	mux.HandleFunc("DELETE /invoices/{id}", s.handle("cancel-invoice", "Id", s.cancelInvoice))
+	mux.HandleFunc("GET /invoices/{id}/status", s.handle("status-invoice", "InvoiceId", s.invoiceStatus))
+	mux.HandleFunc("GET /payments", s.handle("get-list-payments", "", s.listPayments))
+	mux.HandleFunc("GET /payments/{id}", s.handle("get-details-payment", "Id", s.paymentDetails))
+	mux.HandleFunc("POST /cardinvoices", s.handle("add-card-invoice", "", s.addCardInvoice))
+	mux.HandleFunc("GET /cardinvoices/{id}/payment", s.handle("card-invoice-form", "CardInvoiceNo", s.cardInvoiceForm))
+	mux.HandleFunc("GET /cardinvoices/{id}/status", s.handle("status-card-invoice", "CardInvoiceNo", s.cardInvoiceStatus))
+	mux.HandleFunc("POST /cardinvoices/{id}/reverse", s.handle("reverse-card-invoice", "CardInvoiceNo", s.reverseCardInvoice))
+	mux.HandleFunc("POST /web_invoices", s.handle("add-web-invoice", "", s.addWebInvoice))
+	mux.HandleFunc("POST /web_cardinvoices", s.handle("add-webcard-invoice", "", s.addWebCardInvoice))
+	mux.HandleFunc("GET /qrcode/getqrcode", s.handle("get-qr-code", "", s.qrCode))
+	return mux
+}
+
+type request struct {
+	action string
+	id     int
+	params url.Values
+}
+
+func (r *request) get(key string) string {
+	return r.params.Get(key)
+}
+
+type redirect string
+
+type handlerFunc func(r *request) (any, *expresspay.APIError)
+
+func (s *Server) handle(action, idKey string, fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, hr *http.Request) {
+		if err := hr.ParseForm(); err != nil {
+			s.writeError(w, action, &expresspay.APIError{Code: CodeInvalidRequest, Msg: err.Error()})
+			return
+		}
+		req := &request{action: action, params: url.Values{}}
+		for k, v := range hr.Form {
+			req.params[canonicalKey(k)] = v
+		}
+		if idKey != "" {
+			id, err := strconv.Atoi(hr.PathValue("id"))
+			if err != nil {
+				s.writeError(w, action, &expresspay.APIError{Code: CodeInvalidRequest, Msg: "invalid id"})
+				return
+			}
+			req.id = id
+			req.params.Set(canonicalKey(idKey), strconv.Itoa(id))
+		}
+		if apiErr := s.authenticate(req); apiErr != nil {
+			s.writeError(w, action, apiErr)
+			return
+		}
+		if apiErr := s.takeFailure(action); apiErr != nil {
+			s.writeError(w, action, apiErr)
+			return
+		}
+		s.mu.Lock()
+		resp, apiErr := fn(req)
+		s.mu.Unlock()
+		if apiErr != nil {
+			s.writeError(w, action, apiErr)
+			return
+		}
+		if to, ok := resp.(redirect); ok {
+			http.Redirect(w, hr, string(to), http.StatusFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func canonicalKey(k string) string {
+	return strings.ToLower(k)
+}
+
+// authenticate checks the token and signature of a request. Web invoice forms are posted by the
+// customer's browser, so they carry no token: the service is identified by ServiceId and the
+// token only enters the signature.
+func (s *Server) authenticate(r *request) *expresspay.APIError {
+	if isWebAction(r.action) {
+		if s.ServiceID != "" && r.get("serviceid") != s.ServiceID {
+			return &expresspay.APIError{Code: CodeInvalidRequest, Msg: "unknown ServiceId", HTTPStatus: http.StatusUnauthorized}
+		}
+	} else if r.get("token") != s.Token {
+		return &expresspay.APIError{Code: CodeInvalidToken, Msg: "invalid token", HTTPStatus: http.StatusUnauthorized}
+	}
+	if s.Secret == "" {
+		return nil
+	}
+	params := make(map[string]string, len(r.params))
+	for k := range r.params {
+		params[k] = r.get(k)
+	}
+	params["token"] = s.Token
+	expected, err := expresspay.DefaultSignature(r.action, params, s.Secret)
+	if err != nil {
+		return &expresspay.APIError{Code: CodeInvalidRequest, Msg: err.Error()}
+	}
+	if !hmac.Equal([]byte(expected), []byte(strings.ToUpper(r.get("signature")))) {
+		return &expresspay.APIError{Code: CodeInvalidSignature, Msg: "invalid signature", HTTPStatus: http.StatusForbidden}
+	}
+	return nil
+}
+
+// FailNext makes the next request for action (a signature action name such as "add-invoice")
+// fail with err. Calls queue up.
+func (s *Server) FailNext(action string, err expresspay.APIError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[action] = append(s.failures[action], err)
+}
+
+func (s *Server) takeFailure(action string) *expresspay.APIError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.failures[action]
+	if len(queue) == 0 {
+		return nil
+	}
+	err := queue[0]
+	s.failures[action] = queue[1:]
+	return &err
+}
+
+func isWebAction(action string) bool {
+	return action == "add-web-invoice" || action == "add-webcard-invoice"
+}
+
+func isCardAction(action string) bool {
+	switch action {
+	case "add-card-invoice", "card-invoice-form", "status-card-invoice", "reverse-card-invoice":
+		return true
+	}
+	return false
+}
+
+// writeError answers with err. Card endpoints report API errors in a 200 body, as the real API
+// does; only server failures (5xx) keep their status there.
+func (s *Server) writeError(w http.ResponseWriter, action string, err *expresspay.APIError) {
+	status := err.HTTPStatus
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+	if isCardAction(action) {
+		if status < http.StatusInternalServerError {
+			status = http.StatusOK
+		}
+		code := err.ErrorCode
+		if code == 0 {
+			code = err.Code
+		}
+		msg := err.ErrorMessage
+		if msg == "" {
+			msg = err.Msg
+		}
+		writeJSON(w, status, map[string]any{"ErrorCode": code, "ErrorMessage": msg})
+		return
+	}
+	body := *err
+	if body.MsgCode == 0 {
+		body.MsgCode = body.Code
+	}
+	writeJSON(w, status, map[string]any{"Error": body})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if v == nil {
+		v = struct{}{}
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+func notFound(no int) *expresspay.APIError {
+	return &expresspay.APIError{Code: CodeInvoiceNotFound, Msg: fmt.Sprintf("invoice %d not found", no), HTTPStatus: http.StatusNotFound}
+}
+
+func invalid(format string, args ...any) *expresspay.APIError {
+	return &expresspay.APIError{Code: CodeInvalidRequest, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) parseInvoice(r *request) (expresspay.AddInvoiceRequest, *expresspay.APIError) {
+	currency := r.get("currency")
+	amount, err := expresspay.ParseMoney(r.get("amount"), currency)
+	if err != nil || amount.Minor <= 0 {
+		return expresspay.AddInvoiceRequest{}, &expresspay.APIError{Code: CodeAmountInvalid, Msg: "invalid Amount"}
+	}
+	if r.get("accountno") == "" {
+		return expresspay.AddInvoiceRequest{}, invalid("AccountNo is required")
+	}
+	var expiration time.Time
+	if v := r.get("expiration"); v != "" {
+		if expiration, err = time.ParseInLocation(expresspay.DateLayout, v, expresspay.Minsk); err != nil {
+			return expresspay.AddInvoiceRequest{}, invalid("invalid Expiration: %v", err)
+		}
+	}
+	return expresspay.AddInvoiceRequest{
+		AccountNo:         r.get("accountno"),
+		Amount:            amount,
+		Expiration:        expiration,
+		Info:              r.get("info"),
+		Surname:           r.get("surname"),
+		FirstName:         r.get("firstname"),
+		Patronymic:        r.get("patronymic"),
+		City:              r.get("city"),
+		Street:            r.get("street"),
+		House:             r.get("house"),
+		Building:          r.get("building"),
+		Apartment:         r.get("apartment"),
+		IsNameEditable:    r.get("isnameeditable"),
+		IsAddressEditable: r.get("isaddresseditable"),
+		IsAmountEditable:  r.get("isamounteditable"),
+		EmailNotification: r.get("emailnotification"),
+		SmsPhone:          r.get("smsphone"),
+		ReturnInvoiceURL:  r.get("returninvoiceurl"),
+	}, nil
+}
+
+func (s *Server) create(req expresspay.AddInvoiceRequest) *invoice {
+	inv := &invoice{
+		no:      s.nextInvoiceNo,
+		request: req,
+		status:  expresspay.InvoiceStatusPendingPayment,
+		created: s.Now(),
+		paid:    expresspay.NewMoney(0, req.Amount.Currency),
+	}
+	s.nextInvoiceNo++
+	s.invoices[inv.no] = inv
+	return inv
+}
+
+func (s *Server) invoiceURL(no int) string {
+	return fmt.Sprintf("%sinvoices/%d/pay", s.BaseURL(), no)
+}
+
+func (s *Server) addInvoice(r *request) (any, *expresspay.APIError) {
+	req, apiErr := s.parseInvoice(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	inv := s.create(req)
+	resp := map[string]any{"InvoiceNo": inv.no}
+	if r.get("returninvoiceurl") != "" {
+		resp["InvoiceUrl"] = s.invoiceURL(inv.no)
+	}
+	return resp, nil
+}
+
+func (s *Server) addCardInvoice(r *request) (any, *expresspay.APIError) {
+	req, apiErr := s.parseInvoice(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	inv := s.create(req)
+	inv.card = true
+	inv.returnURL = r.get("returnurl")
+	inv.failURL = r.get("failurl")
+	resp := map[string]any{"CardInvoiceNo": inv.no}
+	if r.get("returninvoiceurl") != "" {
+		resp["InvoiceUrl"] = s.invoiceURL(inv.no)
+	}
+	return resp, nil
+}
+
+func (s *Server) addWebInvoice(r *request) (any, *expresspay.APIError) {
+	req, apiErr := s.parseInvoice(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	inv := s.create(req)
+	inv.web = true
+	inv.serviceID = r.get("serviceid")
+	inv.returnURL = r.get("returnurl")
+	inv.failURL = r.get("failurl")
+	if r.get("returntype") == "redirect" {
+		return redirect(s.returnURL(inv, false)), nil
+	}
+	return map[string]any{
+		"InvoiceNo":               inv.no,
+		"InvoiceUrl":              s.invoiceURL(inv.no),
+		"ExpressPayAccountNumber": req.AccountNo,
+		"ExpressPayInvoiceNo":     inv.no,
+		"Signature":               s.responseSignature("add-web-invoice-response", req.AccountNo, inv.no),
+	}, nil
+}
+
+func (s *Server) addWebCardInvoice(r *request) (any, *expresspay.APIError) {
+	req, apiErr := s.parseInvoice(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	inv := s.create(req)
+	inv.card = true
+	inv.web = true
+	inv.serviceID = r.get("serviceid")
+	inv.returnURL = r.get("returnurl")
+	inv.failURL = r.get("failurl")
+	if r.get("returntype") == "redirect" {
+		return redirect(s.formURL(inv.no)), nil
+	}
+	return map[string]any{
+		"FormUrl":                 s.formURL(inv.no),
+		"InvoiceUrl":              s.invoiceURL(inv.no),
+		"ExpressPayAccountNumber": req.AccountNo,
+		"ExpressPayInvoiceNo":     inv.no,
+		"Signature":               s.responseSignature("add-webcard-invoice-response", req.AccountNo, inv.no),
+	}, nil
+}
+
+func (s *Server) responseSignature(action, accountNo string, invoiceNo int) string {
+	if s.Secret == "" {
+		return ""
+	}
+	sig, _ := expresspay.DefaultSignature(action, map[string]string{
+		"ExpressPayAccountNumber": accountNo,
+		"ExpressPayInvoiceNo":     strconv.Itoa(invoiceNo),
+	}, s.Secret)
+	return sig
+}
+
+// ReturnURL builds the signed URL the customer of a web invoice is redirected to: its ReturnUrl,
+// or FailUrl when failed is set.
+func (s *Server) ReturnURL(invoiceNo int, failed bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok || !inv.web {
+		return "", fmt.Errorf("expresspaytest: web invoice %d not found", invoiceNo)
+	}
+	return s.returnURL(inv, failed), nil
+}
+
+func (s *Server) returnURL(inv *invoice, failed bool) string {
+	target := inv.returnURL
+	if failed {
+		target = inv.failURL
+	}
+	action := "add-web-invoice-response"
+	if inv.card {
+		action = "add-webcard-invoice-response"
+	}
+	q := url.Values{}
+	q.Set("ExpressPayAccountNumber", inv.request.AccountNo)
+	q.Set("ExpressPayInvoiceNo", strconv.Itoa(inv.no))
+	if sig := s.responseSignature(action, inv.request.AccountNo, inv.no); sig != "" {
+		q.Set("Signature", sig)
+	}
+	sep := "?"
+	if strings.Contains(target, "?") {
+		sep = "&"
+	}
+	return target + sep + q.Encode()
+}
+
+func (s *Server) formURL(no int) string {
+	return fmt.Sprintf("%scardinvoices/%d/form", s.BaseURL(), no)
+}
+
+func (s *Server) lookup(no int, card bool) (*invoice, *expresspay.APIError) {
+	inv, ok := s.invoices[no]
+	if !ok || (card && !inv.card) {
+		return nil, notFound(no)
+	}
+	return inv, nil
+}
+
+func (s *Server) listInvoices(r *request) (any, *expresspay.APIError) {
+	from, to, apiErr := dateRange(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	items := []map[string]any{}
+	for _, inv := range s.sortedInvoices() {
+		if !inRange(inv.created, from, to) {
+			continue
+		}
+		if v := r.get("accountno"); v != "" && v != inv.request.AccountNo {
+			continue
+		}
+		if v := r.get("status"); v != "" && v != string(inv.status) {
+			continue
+		}
+		item := map[string]any{
+			"InvoiceNo":  inv.no,
+			"AccountNo":  inv.request.AccountNo,
+			"Status":     inv.status,
+			"Created":    stamp(inv.created),
+			"Expiration": date(inv.request.Expiration),
+			"Amount":     inv.request.Amount,
+			"Currency":   json.Number(inv.request.Amount.Currency),
+		}
+		if inv.card {
+			item["CardInvoiceNo"] = inv.no
+		}
+		items = append(items, item)
+	}
+	return map[string]any{"Items": items}, nil
+}
+
+func (s *Server) sortedInvoices() []*invoice {
+	list := make([]*invoice, 0, len(s.invoices))
+	for _, inv := range s.invoices {
+		list = append(list, inv)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].no < list[j].no })
+	return list
+}
+
+func dateRange(r *request) (time.Time, time.Time, *expresspay.APIError) {
+	var from, to time.Time
+	var err error
+	if v := r.get("from"); v != "" {
+		if from, err = time.ParseInLocation(expresspay.DateLayout, v, expresspay.Minsk); err != nil {
+			return from, to, invalid("invalid From: %v", err)
+		}
+	}
+	if v := r.get("to"); v != "" {
+		if to, err = time.ParseInLocation(expresspay.DateLayout, v, expresspay.Minsk); err != nil {
+			return from, to, invalid("invalid To: %v", err)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func stamp(t time.Time) string {
+	return t.In(expresspay.Minsk).Format(expresspay.TimestampLayout)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(expresspay.Minsk).Format(expresspay.DateLayout)
+}
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !t.Before(to) {
+		return false
+	}
+	return true
+}
+
+func (s *Server) invoiceDetails(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	q := inv.request
+	return map[string]any{
+		"Status":            inv.status,
+		"Created":           stamp(inv.created),
+		"Expiration":        date(q.Expiration),
+		"Amount":            q.Amount,
+		"Currency":          json.Number(q.Amount.Currency),
+		"Info":              q.Info,
+		"Surname":           q.Surname,
+		"FirstName":         q.FirstName,
+		"Patronymic":        q.Patronymic,
+		"City":              q.City,
+		"Street":            q.Street,
+		"House":             q.House,
+		"Building":          q.Building,
+		"Apartment":         q.Apartment,
+		"IsNameEditable":    numberOrZero(q.IsNameEditable),
+		"IsAddressEditable": numberOrZero(q.IsAddressEditable),
+		"IsAmountEditable":  numberOrZero(q.IsAmountEditable),
+	}, nil
+}
+
+func numberOrZero(v string) json.Number {
+	if v == "" {
+		return "0"
+	}
+	return json.Number(v)
+}
+
+func (s *Server) invoiceStatus(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return map[string]any{"Status": inv.status}, nil
+}
+
+func (s *Server) cancelInvoice(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if inv.status.IsPaid() {
+		return nil, &expresspay.APIError{Code: CodeInvoiceAlreadyPaid, Msg: fmt.Sprintf("invoice %d is already paid", inv.no)}
+	}
+	if !inv.status.CanTransitionTo(expresspay.InvoiceStatusCanceled) {
+		return nil, &expresspay.APIError{Code: CodeInvalidStatus, Msg: fmt.Sprintf("invoice %d is %s", inv.no, inv.status)}
+	}
+	inv.status = expresspay.InvoiceStatusCanceled
+	return nil, nil
+}
+
+func (s *Server) listPayments(r *request) (any, *expresspay.APIError) {
+	from, to, apiErr := dateRange(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	nos := make([]int, 0, len(s.payments))
+	for no := range s.payments {
+		nos = append(nos, no)
+	}
+	sort.Ints(nos)
+	items := []map[string]any{}
+	for _, no := range nos {
+		p := s.payments[no]
+		inv := s.invoices[p.invoiceNo]
+		if !inRange(p.created, from, to) {
+			continue
+		}
+		if v := r.get("accountno"); v != "" && v != inv.request.AccountNo {
+			continue
+		}
+		item := s.paymentJSON(p, inv)
+		item["PaymentNo"] = p.no
+		items = append(items, item)
+	}
+	return map[string]any{"Items": items}, nil
+}
+
+func (s *Server) paymentJSON(p *payment, inv *invoice) map[string]any {
+	q := inv.request
+	return map[string]any{
+		"AccountNo":  q.AccountNo,
+		"Created":    stamp(p.created),
+		"Amount":     p.amount,
+		"Currency":   json.Number(p.amount.Currency),
+		"Info":       q.Info,
+		"Surname":    q.Surname,
+		"FirstName":  q.FirstName,
+		"Patronymic": q.Patronymic,
+		"City":       q.City,
+		"Street":     q.Street,
+		"House":      q.House,
+		"Building":   q.Building,
+		"Apartment":  q.Apartment,
+	}
+}
+
+func (s *Server) paymentDetails(r *request) (any, *expresspay.APIError) {
+	p, ok := s.payments[r.id]
+	if !ok {
+		return nil, &expresspay.APIError{Code: CodePaymentNotFound, Msg: fmt.Sprintf("payment %d not found", r.id), HTTPStatus: http.StatusNotFound}
+	}
+	return s.paymentJSON(p, s.invoices[p.invoiceNo]), nil
+}
+
+func (s *Server) cardInvoiceForm(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return map[string]any{"FormUrl": s.formURL(inv.no)}, nil
+}
+
+func (s *Server) cardInvoiceStatus(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return map[string]any{
+		"Amount":            inv.request.Amount,
+		"Currency":          json.Number(inv.request.Amount.Currency),
+		"CardInvoiceStatus": cardStatus(inv.status),
+	}, nil
+}
+
+// cardStatus reports the internal invoice status in the codes card invoices use.
+func cardStatus(status expresspay.InvoiceStatus) expresspay.CardInvoiceStatus {
+	switch status {
+	case expresspay.InvoiceStatusPaid, expresspay.InvoiceStatusPaidByBankCard:
+		return expresspay.CardInvoiceStatusAuthorized
+	case expresspay.InvoiceStatusPaymentReturned:
+		return expresspay.CardInvoiceStatusRefunded
+	case expresspay.InvoiceStatusCanceled, expresspay.InvoiceStatusExpired:
+		return expresspay.CardInvoiceStatusCanceled
+	}
+	return expresspay.CardInvoiceStatusRegistered
+}
+
+func (s *Server) reverseCardInvoice(r *request) (any, *expresspay.APIError) {
+	inv, apiErr := s.lookup(r.id, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := s.reverse(inv); err != nil {
+		return nil, &expresspay.APIError{Code: CodeInvalidStatus, Msg: err.Error()}
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) qrCode(r *request) (any, *expresspay.APIError) {
+	no, err := strconv.Atoi(r.get("invoiceid"))
+	if err != nil {
+		return nil, invalid("invalid InvoiceId")
+	}
+	inv, apiErr := s.lookup(no, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	text := fmt.Sprintf("%s|%s|%s|%d", inv.request.AccountNo, inv.request.Amount.Decimal(), inv.request.Amount.Currency, inv.no)
+	if r.get("viewtype") == expresspay.QRCodeViewTypeText {
+		return map[string]any{"QrCodeBody": text}, nil
+	}
+	width, height := atoiDefault(r.get("imagewidth"), 200), atoiDefault(r.get("imageheight"), 200)
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, &expresspay.APIError{Code: CodeInvalidRequest, Msg: err.Error(), HTTPStatus: http.StatusInternalServerError}
+	}
+	return map[string]any{"QrCodeBody": base64.StdEncoding.EncodeToString(buf.Bytes())}, nil
+}
+
+func atoiDefault(v string, def int) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// Pay records a payment against an invoice. A zero amount pays the outstanding balance.
+func (s *Server) Pay(invoiceNo int, amount expresspay.Money) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return 0, fmt.Errorf("expresspaytest: invoice %d not found", invoiceNo)
+	}
+	if inv.status != expresspay.InvoiceStatusPendingPayment && inv.status != expresspay.InvoiceStatusPartiallyPaid {
+		return 0, fmt.Errorf("expresspaytest: invoice %d is %s", invoiceNo, inv.status)
+	}
+	if amount.IsZero() {
+		var err error
+		if amount, err = inv.request.Amount.Sub(inv.paid); err != nil {
+			return 0, err
+		}
+	}
+	if amount.Currency == "" {
+		amount.Currency = inv.request.Amount.Currency
+	}
+	paid, err := inv.paid.Add(amount)
+	if err != nil {
+		return 0, err
+	}
+	inv.paid = paid
+	cmp, _ := paid.Cmp(inv.request.Amount)
+	switch {
+	case cmp < 0:
+		inv.status = expresspay.InvoiceStatusPartiallyPaid
+	case inv.card:
+		inv.status = expresspay.InvoiceStatusPaidByBankCard
+	default:
+		inv.status = expresspay.InvoiceStatusPaid
+	}
+	p := &payment{no: s.nextPaymentNo, invoiceNo: inv.no, amount: amount, created: s.Now()}
+	s.nextPaymentNo++
+	s.payments[p.no] = p
+	inv.paymentNos = append(inv.paymentNos, p.no)
+	return p.no, nil
+}
+
+func (s *Server) Expire(invoiceNo int) error {
+	return s.transition(invoiceNo, expresspay.InvoiceStatusExpired)
+}
+
+func (s *Server) Reverse(invoiceNo int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return fmt.Errorf("expresspaytest: invoice %d not found", invoiceNo)
+	}
+	return s.reverse(inv)
+}
+
+func (s *Server) reverse(inv *invoice) error {
+	if !inv.status.IsPaid() {
+		return fmt.Errorf("expresspaytest: invoice %d is %s", inv.no, inv.status)
+	}
+	inv.status = expresspay.InvoiceStatusPaymentReturned
+	return nil
+}
+
+// SetStatus forces an invoice into status, bypassing the transition rules.
+func (s *Server) SetStatus(invoiceNo int, status expresspay.InvoiceStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return fmt.Errorf("expresspaytest: invoice %d not found", invoiceNo)
+	}
+	inv.status = status
+	return nil
+}
+
+func (s *Server) Status(invoiceNo int) (expresspay.InvoiceStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return "", false
+	}
+	return inv.status, true
+}
+
+func (s *Server) transition(invoiceNo int, status expresspay.InvoiceStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return fmt.Errorf("expresspaytest: invoice %d not found", invoiceNo)
+	}
+	if !inv.status.CanTransitionTo(status) {
+		return fmt.Errorf("expresspaytest: invoice %d cannot move from %s to %s", invoiceNo, inv.status, status)
+	}
+	inv.status = status
+	return nil
+}
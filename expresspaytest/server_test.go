@@ -0,0 +1,190 @@
+package expresspaytest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+// recorder keeps the form of every request sent through it.
+type recorder struct {
+	next  http.RoundTripper
+	forms []url.Values
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		form, _ := url.ParseQuery(string(data))
+		r.forms = append(r.forms, form)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return r.next.RoundTrip(req)
+}
+
+func webInvoice() expresspay.AddWebInvoiceRequest {
+	return expresspay.AddWebInvoiceRequest{
+		ServiceID:  "17",
+		AccountNo:  "order-1",
+		Amount:     expresspay.BYN(1500),
+		ReturnType: "json",
+		ReturnURL:  "https://shop.example/ok",
+		FailURL:    "https://shop.example/fail",
+	}
+}
+
+func TestWebInvoiceFormHasNoToken(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	rec := &recorder{next: srv.Server.Client().Transport}
+	c := srv.Client(expresspay.WithHTTPClient(&http.Client{Transport: rec}))
+
+	resp, err := c.CreateWebInvoice(context.Background(), webInvoice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ExpressPayAccountNumber != "order-1" {
+		t.Errorf("response %+v", resp)
+	}
+	if len(rec.forms) != 1 {
+		t.Fatalf("%d requests", len(rec.forms))
+	}
+	form := rec.forms[0]
+	if _, ok := form["Token"]; ok {
+		t.Error("web invoice form carries the API token")
+	}
+	if form.Get("Signature") == "" {
+		t.Error("web invoice form is not signed")
+	}
+}
+
+func TestWebInvoiceServiceID(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	srv.ServiceID = "18"
+	_, err := srv.Client().CreateWebInvoice(context.Background(), webInvoice())
+	if err == nil {
+		t.Error("unknown ServiceId accepted")
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	ctx := context.Background()
+	req := expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)}
+
+	if _, err := srv.Client().CreateInvoice(ctx, req); err != nil {
+		t.Fatalf("valid credentials: %v", err)
+	}
+	wrongToken := expresspay.NewClient(srv.BaseURL(), "other", "secret", expresspay.WithHTTPClient(srv.Server.Client()))
+	if _, err := wrongToken.CreateInvoice(ctx, req); !expresspay.IsAuth(err) {
+		t.Errorf("wrong token: err = %v", err)
+	}
+	wrongSecret := expresspay.NewClient(srv.BaseURL(), "token", "other", expresspay.WithHTTPClient(srv.Server.Client()))
+	if _, err := wrongSecret.CreateInvoice(ctx, req); !expresspay.IsAuth(err) {
+		t.Errorf("wrong secret: err = %v", err)
+	}
+	if _, err := wrongSecret.CreateWebInvoice(ctx, webInvoice()); !expresspay.IsAuth(err) {
+		t.Errorf("web invoice signed with the wrong secret: err = %v", err)
+	}
+}
+
+func TestInvoiceLifecycle(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	resp, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no64, _ := resp.InvoiceNo.Int64()
+	no := int(no64)
+
+	if _, err := srv.Pay(no, expresspay.BYN(400)); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := c.GetInvoiceStatus(ctx, no); st == nil || st.Status != expresspay.InvoiceStatusPartiallyPaid {
+		t.Errorf("after partial payment: %+v", st)
+	}
+	if _, err := srv.Pay(no, expresspay.Money{}); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := c.GetInvoiceStatus(ctx, no); st == nil || st.Status != expresspay.InvoiceStatusPaid {
+		t.Errorf("after full payment: %+v", st)
+	}
+
+	payments, err := c.ListPayments(ctx, expresspay.ListPaymentsParams{AccountNo: "A-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 || !payments[1].Amount.Equal(expresspay.BYN(600)) {
+		t.Errorf("payments %+v", payments)
+	}
+	if err := c.CancelInvoice(ctx, no); err == nil {
+		t.Error("canceled a paid invoice")
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	resp, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, _ := resp.InvoiceNo.Int64()
+
+	srv.FailNext("status-invoice", expresspay.APIError{Code: 42, Msg: "injected", HTTPStatus: http.StatusConflict})
+	_, err = c.GetInvoiceStatus(ctx, int(no))
+	var apiErr *expresspay.APIError
+	if !errors.As(err, &apiErr) || apiErr.APICode() != 42 || apiErr.HTTPStatus != http.StatusConflict {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := c.GetInvoiceStatus(ctx, int(no)); err != nil {
+		t.Errorf("second call: %v", err)
+	}
+}
+
+// Card endpoints answer errors with status 200 and ErrorCode/ErrorMessage in the body.
+func TestCardErrorsIn200Body(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cardinvoices/999/status?token=token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		ErrorCode    int
+		ErrorMessage string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || body.ErrorCode != expresspaytest.CodeInvoiceNotFound || body.ErrorMessage == "" {
+		t.Errorf("status %d, body %+v", resp.StatusCode, body)
+	}
+
+	_, err = srv.Client().ReverseCardInvoice(context.Background(), 999)
+	var apiErr *expresspay.APIError
+	if !errors.As(err, &apiErr) || apiErr.APICode() != expresspaytest.CodeInvoiceNotFound || apiErr.HTTPStatus != http.StatusOK {
+		t.Errorf("err = %#v", err)
+	}
+}
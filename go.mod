@@ -1,3 +1,3 @@
 module github.com/dizel-by/expresspay
 
-go 1.22
+go 1.23
@@ -0,0 +1,98 @@
+package expresspay
+
+import (
+	"context"
+	"sync"
+)
+
+// CreateInvoiceIdempotent creates an invoice unless one is already pending for r.AccountNo with
+// the same amount and currency, in which case that invoice is returned (with an empty
+// InvoiceURL, which ListInvoices does not report). Calls for the same AccountNo on one Client
+// are serialized, so concurrent callers get the same invoice. Callers in other processes are
+// not coordinated.
+func (c *Client) CreateInvoiceIdempotent(ctx context.Context, r AddInvoiceRequest) (*AddInvoiceResponse, error) {
+	if !c.SkipValidation {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	unlock, err := c.lockAccount(ctx, r.AccountNo)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	existing, err := c.ListInvoices(ctx, ListInvoicesParams{AccountNo: r.AccountNo, Status: InvoiceStatusPendingPayment})
+	if err != nil {
+		return nil, err
+	}
+	var found *Invoice
+	for i := range existing {
+		inv := &existing[i]
+		if inv.AccountNo != r.AccountNo || inv.Status != InvoiceStatusPendingPayment || !inv.Amount.Equal(r.Amount) {
+			continue
+		}
+		if found == nil || inv.Created.After(found.Created.Time) {
+			found = inv
+		}
+	}
+	if found != nil {
+		return &AddInvoiceResponse{InvoiceNo: found.InvoiceNo}, nil
+	}
+	return c.CreateInvoice(ctx, r)
+}
+
+// keyLocks hands out one lock per key and forgets keys nobody holds or waits for.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// accountLocks is shared by Clients not built with NewClient.
+var accountLocks = &keyLocks{}
+
+func (c *Client) lockAccount(ctx context.Context, accountNo string) (func(), error) {
+	locks := c.accountLocks
+	if locks == nil {
+		locks = accountLocks
+	}
+	return locks.lock(ctx, accountNo)
+}
+
+func (k *keyLocks) lock(ctx context.Context, key string) (func(), error) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = map[string]*keyLock{}
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{ch: make(chan struct{}, 1)}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	release := func() {
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
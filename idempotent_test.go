@@ -0,0 +1,97 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+func TestCreateInvoiceIdempotent(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	req := expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(1000)}
+
+	first, err := c.CreateInvoiceIdempotent(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.CreateInvoiceIdempotent(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.InvoiceNo != first.InvoiceNo {
+		t.Errorf("pending invoice %s not reused: got %s", first.InvoiceNo, again.InvoiceNo)
+	}
+
+	other := req
+	other.Amount = expresspay.BYN(1500)
+	changed, err := c.CreateInvoiceIdempotent(ctx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.InvoiceNo == first.InvoiceNo {
+		t.Error("invoice reused for a different amount")
+	}
+
+	no, _ := first.InvoiceNo.Int64()
+	if _, err := srv.Pay(int(no), expresspay.Money{}); err != nil {
+		t.Fatal(err)
+	}
+	afterPayment, err := c.CreateInvoiceIdempotent(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if afterPayment.InvoiceNo == first.InvoiceNo {
+		t.Error("paid invoice reused")
+	}
+}
+
+func TestCreateInvoiceIdempotentConcurrent(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	req := expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(1000)}
+
+	const callers = 8
+	got := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.CreateInvoiceIdempotent(ctx, req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got[i] = resp.InvoiceNo.String()
+		}()
+	}
+	wg.Wait()
+	for _, no := range got[1:] {
+		if no != got[0] {
+			t.Fatalf("concurrent callers got different invoices: %v", got)
+		}
+	}
+	if n := invoiceCount(t, c, "A-1"); n != 1 {
+		t.Errorf("%d invoices created", n)
+	}
+}
+
+func TestCreateInvoiceIdempotentCanceled(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := srv.Client().CreateInvoiceIdempotent(ctx, expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(1000)})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v", err)
+	}
+}
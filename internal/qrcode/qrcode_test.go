@@ -0,0 +1,237 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The 1-M "HELLO WORLD" example used throughout QR Code tutorials (thonky.com).
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("ECC = %v, want %v", got, want)
+	}
+}
+
+// Format and version information strings from the tables of ISO/IEC 18004 annexes C and D.
+func TestFormatBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  string
+	}{
+		{Low, 0, "111011111000100"},
+		{Low, 4, "110011000101111"},
+		{Medium, 0, "101010000010010"},
+		{Quartile, 7, "010101111101101"},
+	}
+	for _, tt := range tests {
+		c := &Code{Version: 1, Size: 21, modules: newGrid(21), isFunction: newGrid(21)}
+		c.drawFormatBits(tt.level, tt.mask)
+		var first, second strings.Builder
+		for i := 14; i >= 0; i-- {
+			first.WriteString(bit(c.Dark(formatPosition(c.Size, i))))
+			second.WriteString(bit(c.Dark(formatCopyPosition(c.Size, i))))
+		}
+		if first.String() != tt.want || second.String() != tt.want {
+			t.Errorf("level %d mask %d: format bits %s and %s, want %s", tt.level, tt.mask, first.String(), second.String(), tt.want)
+		}
+		if !c.Dark(8, c.Size-8) {
+			t.Error("dark module missing")
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	tests := []struct {
+		version int
+		want    string
+	}{
+		{7, "000111110010010100"},
+		{8, "001000010110111100"},
+		{40, "101000110001101001"},
+	}
+	for _, tt := range tests {
+		size := tt.version*4 + 17
+		c := &Code{Version: tt.version, Size: size, modules: newGrid(size), isFunction: newGrid(size)}
+		c.drawVersion()
+		var upper, lower strings.Builder
+		for i := 17; i >= 0; i-- {
+			upper.WriteString(bit(c.Dark(size-11+i%3, i/3)))
+			lower.WriteString(bit(c.Dark(i/3, size-11+i%3)))
+		}
+		if upper.String() != tt.want || lower.String() != tt.want {
+			t.Errorf("version %d: %s and %s, want %s", tt.version, upper.String(), lower.String(), tt.want)
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		36: {6, 24, 50, 76, 102, 128, 154},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if got := alignmentPositions(version); !slices.Equal(got, want) {
+			t.Errorf("version %d: %v, want %v", version, got, want)
+		}
+	}
+}
+
+// Byte mode capacities from table 7 of the specification.
+func TestCapacity(t *testing.T) {
+	tests := []struct {
+		level   Level
+		version int
+		bytes   int
+	}{
+		{Low, 1, 17},
+		{Medium, 1, 14},
+		{Quartile, 1, 11},
+		{High, 1, 7},
+		{Medium, 10, 213},
+		{Low, 40, 2953},
+		{High, 40, 1273},
+	}
+	for _, tt := range tests {
+		c, err := Encode(make([]byte, tt.bytes), tt.level)
+		if err != nil || c.Version != tt.version {
+			t.Errorf("%d bytes at level %d: version %v, err %v; want version %d", tt.bytes, tt.level, c, err, tt.version)
+			continue
+		}
+		c, err = Encode(make([]byte, tt.bytes+1), tt.level)
+		if tt.version == 40 {
+			if !errors.Is(err, ErrTooLong) {
+				t.Errorf("%d bytes at level %d: err = %v", tt.bytes+1, tt.level, err)
+			}
+		} else if err != nil || c.Version != tt.version+1 {
+			t.Errorf("%d bytes at level %d: version %v, err %v", tt.bytes+1, tt.level, c, err)
+		}
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	c, err := Encode([]byte("https://example.com/pay?invoice=123456"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := max(abs(dx-3), abs(dy-3))
+				if got := c.Dark(corner[0]+dx, corner[1]+dy); got != (ring != 2) {
+					t.Fatalf("finder at %v: module (%d, %d) dark = %v", corner, dx, dy, got)
+				}
+			}
+		}
+	}
+	for i := 8; i < c.Size-8; i++ {
+		if c.Dark(i, 6) != (i%2 == 0) || c.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern broken at %d", i)
+		}
+	}
+}
+
+// Reading a version 1 symbol back in placement order must give the data codewords of the
+// input: mode 0100, an 8-bit count, the bytes, a terminator and the 0xEC 0x11 padding.
+func TestEncodeReadBack(t *testing.T) {
+	c, err := Encode([]byte("hello"), Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 1 {
+		t.Fatalf("version %d", c.Version)
+	}
+	var format int
+	for i := 14; i >= 0; i-- {
+		format <<= 1
+		if c.Dark(formatPosition(c.Size, i)) {
+			format |= 1
+		}
+	}
+	format ^= 0x5412
+	if level := format >> 13; level != formatBits[Low] {
+		t.Fatalf("format %015b does not encode level L", format)
+	}
+	mask := format >> 10 & 7
+	c.applyMask(mask)
+	got := readCodewords(c)
+	c.applyMask(mask)
+
+	data := []byte{64, 86, 134, 86, 198, 198, 240, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17}
+	want := append(data, reedSolomonRemainder(data, reedSolomonDivisor(7))...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("codewords\n%v\nwant\n%v", got, want)
+	}
+}
+
+// readCodewords walks the data modules in the order drawCodewords fills them.
+func readCodewords(c *Code) []byte {
+	var out []byte
+	var cur byte
+	n := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for _, x := range []int{right, right - 1} {
+				if c.isFunction[y][x] {
+					continue
+				}
+				cur <<= 1
+				if c.modules[y][x] {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					out = append(out, cur)
+					cur = 0
+				}
+			}
+		}
+	}
+	return out
+}
+
+// formatPosition is the module holding format bit i next to the top-left finder.
+func formatPosition(size, i int) (x, y int) {
+	switch {
+	case i <= 5:
+		return 8, i
+	case i == 6:
+		return 8, 7
+	case i == 7:
+		return 8, 8
+	case i == 8:
+		return 7, 8
+	}
+	return 14 - i, 8
+}
+
+// formatCopyPosition is the module holding the second copy of format bit i.
+func formatCopyPosition(size, i int) (x, y int) {
+	if i < 8 {
+		return size - 1 - i, 8
+	}
+	return 8, size - 15 + i
+}
+
+func bit(dark bool) string {
+	if dark {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,103 @@
+package expresspay
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.Logger = l
+	}
+}
+
+var sensitiveKeys = map[string]bool{
+	"token":             true,
+	"signature":         true,
+	"secret":            true,
+	"smsphone":          true,
+	"emailnotification": true,
+}
+
+var (
+	secretParamRe = regexp.MustCompile(`(?i)\b(token|signature|secret)=[^&\s"]*`)
+	secretJSONRe  = regexp.MustCompile(`(?i)("(?:token|signature|secret|smsphone|emailnotification)"\s*:\s*)"[^"]*"`)
+	emailRe       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phoneRe       = regexp.MustCompile(`\+?\b375\d{9}\b`)
+)
+
+func redactValues(v url.Values) string {
+	if len(v) == 0 {
+		return ""
+	}
+	out := make(url.Values, len(v))
+	for k, vals := range v {
+		if sensitiveKeys[strings.ToLower(k)] {
+			out[k] = []string{redacted}
+			continue
+		}
+		out[k] = vals
+	}
+	s, _ := url.QueryUnescape(out.Encode())
+	return redactText(s)
+}
+
+// redactText masks secrets, e-mails and phone numbers in free text such as response bodies and
+// transport errors, which embed the full request URL.
+func redactText(s string) string {
+	s = secretParamRe.ReplaceAllString(s, "${1}="+redacted)
+	s = secretJSONRe.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	s = emailRe.ReplaceAllString(s, redacted)
+	return phoneRe.ReplaceAllString(s, redacted)
+}
+
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	return c.Logger != nil && c.Logger.Enabled(ctx, slog.LevelDebug)
+}
+
+func (c *Client) logExchange(ctx context.Context, method, path string, query, form url.Values, attempt, status int, d time.Duration, body []byte, err error) {
+	if c.Logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Duration("duration", d),
+	}
+	if attempt > 1 {
+		attrs = append(attrs, slog.Int("attempt", attempt))
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.APICode() != 0 {
+		attrs = append(attrs, slog.Int("api_code", apiErr.APICode()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactText(err.Error())))
+	}
+	if c.debugEnabled(ctx) {
+		if q := redactValues(query); q != "" {
+			attrs = append(attrs, slog.String("query", q))
+		}
+		if f := redactValues(form); f != "" {
+			attrs = append(attrs, slog.String("form", f))
+		}
+		if len(body) > 0 {
+			attrs = append(attrs, slog.String("body", redactText(string(body))))
+		}
+	}
+	level, msg := slog.LevelInfo, "expresspay request"
+	if err != nil {
+		level, msg = slog.LevelWarn, "expresspay request failed"
+	}
+	c.Logger.LogAttrs(ctx, level, msg, attrs...)
+}
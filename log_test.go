@@ -0,0 +1,108 @@
+package expresspay_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+var signatureRe = regexp.MustCompile(`(?i)signature"?\s*[=:]\s*"?([0-9a-f]{40})`)
+
+// signatureSniffer collects every signature sent to or received from the server.
+type signatureSniffer struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (s *signatureSniffer) RoundTrip(req *http.Request) (*http.Response, error) {
+	text := req.URL.String()
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		text += string(data)
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	text += string(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range signatureRe.FindAllStringSubmatch(text, -1) {
+		s.seen = append(s.seen, m[1])
+	}
+	return resp, nil
+}
+
+func TestLogRedactsSecrets(t *testing.T) {
+	const (
+		token  = "tok-7f3a9c"
+		secret = "sec-51b2e8"
+		phone  = "375291234567"
+		email  = "payer@example.com"
+	)
+	srv := expresspaytest.NewServer(token, secret)
+	defer srv.Close()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sniffer := &signatureSniffer{}
+	c := srv.Client(expresspay.WithLogger(logger), expresspay.WithHTTPClient(&http.Client{Transport: sniffer}))
+	ctx := context.Background()
+
+	resp, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{
+		AccountNo:         "A-1",
+		Amount:            expresspay.BYN(100),
+		SmsPhone:          phone,
+		EmailNotification: email,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, _ := resp.InvoiceNo.Int64()
+	if _, err := c.GetInvoiceStatus(ctx, int(no)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateWebInvoice(ctx, webInvoiceRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetInvoiceStatus(ctx, 999); err == nil {
+		t.Fatal("want error")
+	}
+	// Transport errors embed the full URL, token included.
+	down := expresspay.NewClient("http://127.0.0.1:1/", token, secret, expresspay.WithLogger(logger))
+	if _, err := down.GetInvoiceStatus(ctx, 1); err == nil {
+		t.Fatal("want error")
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "body=") || !strings.Contains(out, "request failed") {
+		t.Fatalf("debug log incomplete:\n%s", out)
+	}
+	if len(sniffer.seen) < 3 {
+		t.Fatalf("only %d signatures exchanged", len(sniffer.seen))
+	}
+	for _, s := range append([]string{token, secret, phone, email}, sniffer.seen...) {
+		if strings.Contains(strings.ToUpper(out), strings.ToUpper(s)) {
+			t.Errorf("log contains %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package expresspay
+
+import (
+	"errors"
+	"expvar"
+	"strconv"
+	"time"
+)
+
+// Metrics receives client instrumentation. RequestDone is called once per HTTP attempt, retries
+// included, with the action name used for signatures (e.g. "add-invoice"), the HTTP status (0 for
+// transport errors), the Express Pay error code (0 when there is none; card endpoints send it in
+// a 200 body) and the attempt duration.
+// The other methods count successful business operations. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	RequestDone(action string, status, errorCode int, d time.Duration)
+	InvoiceCreated(kind InvoiceKind)
+	InvoiceCanceled()
+	CardInvoiceReversed()
+}
+
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.Metrics = m
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RequestDone(string, int, int, time.Duration) {}
+func (nopMetrics) InvoiceCreated(InvoiceKind)                  {}
+func (nopMetrics) InvoiceCanceled()                            {}
+func (nopMetrics) CardInvoiceReversed()                        {}
+
+func (c *Client) metrics() Metrics {
+	if c.Metrics == nil {
+		return nopMetrics{}
+	}
+	return c.Metrics
+}
+
+func (c *Client) observeRequest(action string, status int, d time.Duration, err error) {
+	if c.Metrics == nil {
+		return
+	}
+	code := 0
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		code = apiErr.APICode()
+	}
+	c.Metrics.RequestDone(action, status, code, d)
+}
+
+// ExpvarMetrics publishes metrics as an expvar map, served at /debug/vars by the expvar handler:
+//
+//	requests          count per action
+//	request_errors    count per action of attempts that failed
+//	statuses          count per "action status"
+//	api_errors        count per "action code"
+//	duration_seconds  total attempt time per action
+//	invoices_created  count per InvoiceKind
+//	invoices_canceled, card_invoices_reversed
+type ExpvarMetrics struct {
+	requests             *expvar.Map
+	requestErrors        *expvar.Map
+	statuses             *expvar.Map
+	apiErrors            *expvar.Map
+	duration             *expvar.Map
+	invoicesCreated      *expvar.Map
+	invoicesCanceled     *expvar.Int
+	cardInvoicesReversed *expvar.Int
+}
+
+// NewExpvarMetrics publishes the metrics under name. Like expvar.Publish, it panics if name is
+// already in use, so call it once per process.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		requests:             new(expvar.Map),
+		requestErrors:        new(expvar.Map),
+		statuses:             new(expvar.Map),
+		apiErrors:            new(expvar.Map),
+		duration:             new(expvar.Map),
+		invoicesCreated:      new(expvar.Map),
+		invoicesCanceled:     new(expvar.Int),
+		cardInvoicesReversed: new(expvar.Int),
+	}
+	root := expvar.NewMap(name)
+	root.Set("requests", m.requests)
+	root.Set("request_errors", m.requestErrors)
+	root.Set("statuses", m.statuses)
+	root.Set("api_errors", m.apiErrors)
+	root.Set("duration_seconds", m.duration)
+	root.Set("invoices_created", m.invoicesCreated)
+	root.Set("invoices_canceled", m.invoicesCanceled)
+	root.Set("card_invoices_reversed", m.cardInvoicesReversed)
+	return m
+}
+
+func (m *ExpvarMetrics) RequestDone(action string, status, errorCode int, d time.Duration) {
+	m.requests.Add(action, 1)
+	if status == 0 || status >= 400 || errorCode != 0 {
+		m.requestErrors.Add(action, 1)
+	}
+	m.statuses.Add(action+" "+strconv.Itoa(status), 1)
+	if errorCode != 0 {
+		m.apiErrors.Add(action+" "+strconv.Itoa(errorCode), 1)
+	}
+	m.duration.AddFloat(action, d.Seconds())
+}
+
+func (m *ExpvarMetrics) InvoiceCreated(kind InvoiceKind) {
+	m.invoicesCreated.Add(string(kind), 1)
+}
+
+func (m *ExpvarMetrics) InvoiceCanceled() {
+	m.invoicesCanceled.Add(1)
+}
+
+func (m *ExpvarMetrics) CardInvoiceReversed() {
+	m.cardInvoicesReversed.Add(1)
+}
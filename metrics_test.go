@@ -0,0 +1,134 @@
+package expresspay_test
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+type recordedMetrics struct {
+	mu       sync.Mutex
+	requests []string
+	created  []expresspay.InvoiceKind
+}
+
+func (m *recordedMetrics) RequestDone(action string, status, errorCode int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %d %d", action, status, errorCode))
+}
+
+func (m *recordedMetrics) InvoiceCreated(kind expresspay.InvoiceKind) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created = append(m.created, kind)
+}
+
+func (m *recordedMetrics) InvoiceCanceled()     {}
+func (m *recordedMetrics) CardInvoiceReversed() {}
+
+func TestMetricsActions(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	m := &recordedMetrics{}
+	c := srv.Client(expresspay.WithMetrics(m))
+	ctx := context.Background()
+
+	resp, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, _ := resp.InvoiceNo.Int64()
+	if _, err := c.GetInvoiceStatus(ctx, int(no)); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range c.InvoicesSeq(ctx, expresspay.ListInvoicesParams{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.GetInvoiceStatus(ctx, 999); err == nil {
+		t.Fatal("want error")
+	}
+
+	want := []string{
+		"add-invoice 200 0",
+		"status-invoice 200 0",
+		"get-list-invoices 200 0",
+		fmt.Sprintf("status-invoice 404 %d", expresspaytest.CodeInvoiceNotFound),
+	}
+	if fmt.Sprint(m.requests) != fmt.Sprint(want) {
+		t.Errorf("requests %q, want %q", m.requests, want)
+	}
+	if len(m.created) != 1 || m.created[0] != expresspay.InvoiceKindERIP {
+		t.Errorf("created %v", m.created)
+	}
+}
+
+// Card endpoints report errors in a 200 body; the metrics must see the code.
+func TestMetricsCardErrorCode(t *testing.T) {
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n == 1 {
+			w.Write([]byte(`{"ErrorCode": 12, "ErrorMessage": "card declined"}`))
+			return
+		}
+		w.Write([]byte(`{"ErrorCode": 5}`))
+	})
+	m := &recordedMetrics{}
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithMetrics(m))
+	ctx := context.Background()
+
+	if _, err := c.GetCardInvoiceStatus(ctx, 1, ""); err == nil || err.Error() != "card declined" {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := c.ReverseCardInvoice(ctx, 1); err == nil || err.Error() != "expresspay: error code 5" {
+		t.Errorf("code without message: err = %v", err)
+	}
+	want := []string{"status-card-invoice 200 12", "reverse-card-invoice 200 5"}
+	if fmt.Sprint(m.requests) != fmt.Sprint(want) {
+		t.Errorf("requests %q, want %q", m.requests, want)
+	}
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n == 1 {
+			w.Write([]byte(`{"Status": 1}`))
+			return
+		}
+		w.Write([]byte(`{"ErrorCode": 12, "ErrorMessage": "card declined"}`))
+	})
+	m := expresspay.NewExpvarMetrics("expresspay_test")
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithMetrics(m))
+	ctx := context.Background()
+	c.GetInvoiceStatus(ctx, 1)
+	c.GetCardInvoiceStatus(ctx, 1, "")
+
+	root := expvar.Get("expresspay_test").(*expvar.Map)
+	get := func(group, key string) string {
+		v := root.Get(group).(*expvar.Map).Get(key)
+		if v == nil {
+			return "<nil>"
+		}
+		return v.String()
+	}
+	checks := map[[2]string]string{
+		{"requests", "status-invoice"}:            "1",
+		{"request_errors", "status-invoice"}:      "<nil>",
+		{"request_errors", "status-card-invoice"}: "1",
+		{"api_errors", "status-card-invoice 12"}:  "1",
+		{"statuses", "status-card-invoice 200"}:   "1",
+	}
+	for k, want := range checks {
+		if got := get(k[0], k[1]); got != want {
+			t.Errorf("%s[%q] = %s, want %s", k[0], k[1], got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package expresspay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("expresspay: currency mismatch")
+	ErrMoneyOverflow    = errors.New("expresspay: money overflow")
+)
+
+// Money is an amount in minor units (kopecks, cents) of an ISO 4217 numeric currency.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+var currencyNames = map[string]string{
+	CurrencyBYN: "BYN",
+	CurrencyEUR: "EUR",
+	CurrencyUSD: "USD",
+	CurrencyRUB: "RUB",
+}
+
+// CurrencyName returns the ISO 4217 letter code for a numeric currency code, or the code itself
+// when it is not one of the Currency constants.
+func CurrencyName(code string) string {
+	if name, ok := currencyNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+func BYN(minor int64) Money { return NewMoney(minor, CurrencyBYN) }
+func USD(minor int64) Money { return NewMoney(minor, CurrencyUSD) }
+func EUR(minor int64) Money { return NewMoney(minor, CurrencyEUR) }
+func RUB(minor int64) Money { return NewMoney(minor, CurrencyRUB) }
+
+// ParseMoney accepts both the API format ("10,00") and a dot decimal separator ("10.00").
+func ParseMoney(s, currency string) (Money, error) {
+	minor, err := parseMinor(s)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func parseMinor(s string) (int64, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("expresspay: invalid amount %q", raw)
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	whole, frac := s, ""
+	if i := strings.IndexAny(s, ",."); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("expresspay: invalid amount %q", raw)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("expresspay: amount %q has more than 2 decimal places", raw)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("expresspay: invalid amount %q", raw)
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expresspay: invalid amount %q: %w", raw, ErrMoneyOverflow)
+	}
+	f, _ := strconv.ParseInt(frac, 10, 64)
+	if w > (math.MaxInt64-f)/100 {
+		return 0, fmt.Errorf("expresspay: invalid amount %q: %w", raw, ErrMoneyOverflow)
+	}
+	minor := w*100 + f
+	if neg {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount the way the API expects it: "10,00".
+func (m Money) String() string {
+	return m.format(',')
+}
+
+// Decimal formats the amount with a dot separator: "10.00".
+func (m Money) Decimal() string {
+	return m.format('.')
+}
+
+func (m Money) format(sep byte) string {
+	v := m.Minor
+	sign := ""
+	var u uint64
+	if v < 0 {
+		sign = "-"
+		u = uint64(-(v + 1)) + 1
+	} else {
+		u = uint64(v)
+	}
+	return fmt.Sprintf("%s%d%c%02d", sign, u/100, sep, u%100)
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Minor > 0 && m.Minor > math.MaxInt64-o.Minor) || (o.Minor < 0 && m.Minor < math.MinInt64-o.Minor) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Minor == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(Money{Minor: -o.Minor, Currency: o.Currency})
+}
+
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Minor == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	r := m.Minor * n
+	if r/n != m.Minor || (m.Minor == -1 && n == math.MinInt64) || (n == -1 && m.Minor == math.MinInt64) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Minor: r, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or +1 depending on whether m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) Equal(o Money) bool {
+	return m.Currency == o.Currency && m.Minor == o.Minor
+}
+
+// MarshalJSON encodes only the amount, as a JSON number; the currency travels in its own field.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers and strings in either decimal format. The currency is left
+// untouched and filled from the sibling Currency field by the client.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			m.Minor = 0
+			return nil
+		}
+	}
+	minor, err := parseMinor(s)
+	if err != nil {
+		return err
+	}
+	m.Minor = minor
+	return nil
+}
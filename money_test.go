@@ -0,0 +1,144 @@
+package expresspay_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"10,00", 1000},
+		{"10.5", 1050},
+		{"0,01", 1},
+		{",5", 50},
+		{"7", 700},
+		{" 12,30 ", 1230},
+		{"1,500", 150},
+		{"-3,25", -325},
+		{"+3", 300},
+		{"92233720368547758,07", math.MaxInt64},
+	}
+	for _, tt := range tests {
+		m, err := expresspay.ParseMoney(tt.in, expresspay.CurrencyBYN)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", tt.in, err)
+			continue
+		}
+		if m.Minor != tt.want || m.Currency != expresspay.CurrencyBYN {
+			t.Errorf("ParseMoney(%q) = %+v, want %d", tt.in, m, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1,234", "1,2,3", "1e3", "-", ",", "92233720368547758,08"} {
+		if _, err := expresspay.ParseMoney(in, expresspay.CurrencyBYN); err == nil {
+			t.Errorf("ParseMoney(%q): want error", in)
+		}
+	}
+	if _, err := expresspay.ParseMoney("99999999999999999999", ""); !errors.Is(err, expresspay.ErrMoneyOverflow) {
+		t.Errorf("overflow: err = %v", err)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		m             expresspay.Money
+		api, decimals string
+	}{
+		{expresspay.BYN(1050), "10,50", "10.50"},
+		{expresspay.BYN(5), "0,05", "0.05"},
+		{expresspay.BYN(0), "0,00", "0.00"},
+		{expresspay.BYN(-101), "-1,01", "-1.01"},
+		{expresspay.BYN(math.MinInt64), "-92233720368547758,08", "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.api {
+			t.Errorf("%d String() = %q, want %q", tt.m.Minor, got, tt.api)
+		}
+		if got := tt.m.Decimal(); got != tt.decimals {
+			t.Errorf("%d Decimal() = %q, want %q", tt.m.Minor, got, tt.decimals)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := expresspay.BYN(150).Add(expresspay.BYN(250))
+	if err != nil || !sum.Equal(expresspay.BYN(400)) {
+		t.Errorf("Add = %+v, %v", sum, err)
+	}
+	diff, err := expresspay.BYN(150).Sub(expresspay.BYN(250))
+	if err != nil || !diff.Equal(expresspay.BYN(-100)) {
+		t.Errorf("Sub = %+v, %v", diff, err)
+	}
+	prod, err := expresspay.BYN(150).Mul(3)
+	if err != nil || !prod.Equal(expresspay.BYN(450)) {
+		t.Errorf("Mul = %+v, %v", prod, err)
+	}
+
+	if _, err := expresspay.BYN(1).Add(expresspay.USD(1)); !errors.Is(err, expresspay.ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: err = %v", err)
+	}
+	if _, err := expresspay.BYN(1).Cmp(expresspay.USD(1)); !errors.Is(err, expresspay.ErrCurrencyMismatch) {
+		t.Errorf("Cmp across currencies: err = %v", err)
+	}
+	if _, err := expresspay.BYN(math.MaxInt64).Add(expresspay.BYN(1)); !errors.Is(err, expresspay.ErrMoneyOverflow) {
+		t.Errorf("Add overflow: err = %v", err)
+	}
+	if _, err := expresspay.BYN(0).Sub(expresspay.BYN(math.MinInt64)); !errors.Is(err, expresspay.ErrMoneyOverflow) {
+		t.Errorf("Sub overflow: err = %v", err)
+	}
+	if _, err := expresspay.BYN(math.MaxInt64 / 2).Mul(3); !errors.Is(err, expresspay.ErrMoneyOverflow) {
+		t.Errorf("Mul overflow: err = %v", err)
+	}
+	if c, err := expresspay.BYN(1).Cmp(expresspay.BYN(2)); err != nil || c != -1 {
+		t.Errorf("Cmp = %d, %v", c, err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A, B, C expresspay.Money
+	}
+	if err := json.Unmarshal([]byte(`{"A": 10.5, "B": "7,25", "C": ""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.Minor != 1050 || v.B.Minor != 725 || v.C.Minor != 0 {
+		t.Errorf("decoded %+v", v)
+	}
+	data, err := json.Marshal(expresspay.BYN(1050))
+	if err != nil || string(data) != "10.50" {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
+
+// The currency sent to the API is always that of Amount, even with validation off.
+func TestRequestSendsAmountCurrency(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`{"InvoiceNo": 1}`))
+	}))
+	defer srv.Close()
+
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithoutValidation())
+	if _, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{
+		AccountNo: "A-1",
+		Amount:    expresspay.USD(1999),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("Amount") != "19,99" || form.Get("Currency") != expresspay.CurrencyUSD {
+		t.Errorf("sent Amount=%q Currency=%q", form.Get("Amount"), form.Get("Currency"))
+	}
+}
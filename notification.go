@@ -0,0 +1,194 @@
+package expresspay
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	NotificationNewPayment      = 1
+	NotificationPaymentCanceled = 2
+	NotificationStatusChanged   = 3
+	NotificationCardPayment     = 6
+)
+
+var (
+	ErrNotificationSignature = errors.New("expresspay: notification signature mismatch")
+	ErrNotificationPayload   = errors.New("expresspay: malformed notification payload")
+)
+
+type Notification struct {
+	CmdType       json.Number   `json:"CmdType"`
+	PaymentNo     json.Number   `json:"PaymentNo"`
+	InvoiceNo     json.Number   `json:"InvoiceNo"`
+	CardInvoiceNo json.Number   `json:"CardInvoiceNo"`
+	AccountNo     string        `json:"AccountNo"`
+	Amount        Money         `json:"Amount"`
+	Currency      json.Number   `json:"Currency"`
+	Status        InvoiceStatus `json:"Status"`
+	Created       Time          `json:"Created"`
+	Service       string        `json:"Service"`
+	Payer         string        `json:"Payer"`
+	Address       string        `json:"Address"`
+	Data          string        `json:"-"`
+	Signature     string        `json:"-"`
+}
+
+func (n *Notification) Type() int {
+	return intFromNumber(n.CmdType)
+}
+
+type NotificationFunc func(ctx context.Context, n *Notification) error
+
+type NotificationHandler struct {
+	Secret       string
+	UseSignature bool
+	// Store, if set, receives the status changes and payments reported by notifications.
+	Store Store
+
+	OnNewPayment      NotificationFunc
+	OnPaymentCanceled NotificationFunc
+	OnStatusChanged   NotificationFunc
+	OnCardPayment     NotificationFunc
+	OnUnknown         NotificationFunc
+	OnEvent           EventFunc
+	OnError           func(r *http.Request, err error)
+}
+
+func (c *Client) NotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		Secret:       c.Secret,
+		UseSignature: c.UseSignature,
+		Store:        c.Store,
+	}
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	n, err := h.Parse(r)
+	if err != nil {
+		h.reportError(r, err)
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrNotificationSignature) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if err := h.dispatch(r.Context(), n); err != nil {
+		h.reportError(r, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *NotificationHandler) Parse(r *http.Request) (*Notification, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotificationPayload, err)
+	}
+	data := r.PostForm.Get("Data")
+	if data == "" {
+		return nil, fmt.Errorf("%w: empty Data", ErrNotificationPayload)
+	}
+	signature := r.PostForm.Get("Signature")
+	if err := h.Verify(data, signature); err != nil {
+		return nil, err
+	}
+	var n Notification
+	if err := decodeJSON([]byte(data), &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotificationPayload, err)
+	}
+	n.Amount.Currency = n.Currency.String()
+	n.Data = data
+	n.Signature = signature
+	return &n, nil
+}
+
+// Verify checks a notification signature. Like response signatures it is made by Express Pay, so
+// DefaultSignature is used even when the client signs requests with a custom SignatureFunc.
+func (h *NotificationHandler) Verify(data, signature string) error {
+	if !h.UseSignature && h.Secret == "" {
+		return nil
+	}
+	expected, err := DefaultSignature("notification", map[string]string{"Data": data}, h.Secret)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(strings.ToUpper(signature))) {
+		return ErrNotificationSignature
+	}
+	return nil
+}
+
+func (h *NotificationHandler) dispatch(ctx context.Context, n *Notification) error {
+	if err := h.record(ctx, n); err != nil {
+		return err
+	}
+	var fn NotificationFunc
+	switch n.Type() {
+	case NotificationNewPayment:
+		fn = h.OnNewPayment
+	case NotificationPaymentCanceled:
+		fn = h.OnPaymentCanceled
+	case NotificationStatusChanged:
+		fn = h.OnStatusChanged
+	case NotificationCardPayment:
+		fn = h.OnCardPayment
+	default:
+		fn = h.OnUnknown
+	}
+	if fn != nil {
+		if err := fn(ctx, n); err != nil {
+			return err
+		}
+	}
+	if h.OnEvent != nil {
+		if e, ok := n.Event(); ok {
+			return h.OnEvent(ctx, e)
+		}
+	}
+	return nil
+}
+
+func (h *NotificationHandler) record(ctx context.Context, n *Notification) error {
+	if h.Store == nil {
+		return nil
+	}
+	kind, no := InvoiceKindERIP, n.InvoiceNo
+	if n.Type() == NotificationCardPayment || (no == "" && n.CardInvoiceNo != "") {
+		kind, no = InvoiceKindCard, n.CardInvoiceNo
+	}
+	if no == "" {
+		return nil
+	}
+	switch n.Type() {
+	case NotificationNewPayment, NotificationCardPayment:
+		if n.PaymentNo != "" {
+			return h.Store.LinkPayment(ctx, kind, no.String(), n.PaymentNo.String())
+		}
+	case NotificationStatusChanged:
+		at := n.Created.Time
+		if at.IsZero() {
+			at = time.Now()
+		}
+		return h.Store.UpdateStatus(ctx, kind, no.String(), n.Status, at)
+	}
+	return nil
+}
+
+func (h *NotificationHandler) reportError(r *http.Request, err error) {
+	if h.OnError != nil {
+		h.OnError(r, err)
+	}
+}
@@ -0,0 +1,128 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+)
+
+// Reference values computed with: printf '%s' <message> | openssl dgst -sha1 -hmac secret
+const (
+	testNotificationData      = `{"CmdType":1,"PaymentNo":7,"AccountNo":"A-1","Amount":"5,00","Currency":933}`
+	testNotificationSignature = "7EB9F45ACAF0B3803926305ADA7FE896FCBB355C"
+)
+
+func TestDefaultSignature(t *testing.T) {
+	// Fields are concatenated in the documented order, whatever the map order or key case.
+	sig, err := expresspay.DefaultSignature("add-invoice", map[string]string{
+		"currency":  "933",
+		"Amount":    "10,00",
+		"ACCOUNTNO": "ACC-1",
+		"Token":     "tok123",
+		"Unknown":   "ignored",
+	}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "61ADA7FF0FF64D8BAFEB13D2918340D16AEFB0FD"; sig != want {
+		t.Errorf("signature = %s, want %s", sig, want)
+	}
+
+	if _, err := expresspay.DefaultSignature("no-such-action", nil, "secret"); err == nil {
+		t.Error("unknown action: want error")
+	}
+}
+
+func notificationRequest(data, signature string) *http.Request {
+	form := url.Values{"Data": {data}, "Signature": {signature}}
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestNotificationHandlerVerifiesSignature(t *testing.T) {
+	var got *expresspay.Notification
+	h := &expresspay.NotificationHandler{
+		Secret: "secret",
+		OnNewPayment: func(ctx context.Context, n *expresspay.Notification) error {
+			got = n
+			return nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, notificationRequest(testNotificationData, strings.ToLower(testNotificationSignature)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid signature: status %d", w.Code)
+	}
+	if got == nil {
+		t.Fatal("OnNewPayment not called")
+	}
+	if got.PaymentNo != "7" || got.AccountNo != "A-1" || !got.Amount.Equal(expresspay.BYN(500)) {
+		t.Errorf("notification = %+v", got)
+	}
+
+	got = nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, notificationRequest(testNotificationData, "00"+testNotificationSignature[2:]))
+	if w.Code != http.StatusForbidden || got != nil {
+		t.Errorf("bad signature: status %d, dispatched %v", w.Code, got != nil)
+	}
+}
+
+// A SignatureFunc that only knows the request actions must not break notification checks.
+func TestClientNotificationHandlerCustomSignature(t *testing.T) {
+	requestsOnly := func(action string, params map[string]string, secret string) (string, error) {
+		if action == "notification" {
+			return "", errors.New("unexpected action " + action)
+		}
+		return expresspay.DefaultSignature(action, params, secret)
+	}
+	c := expresspay.NewClient("http://127.0.0.1", "token", "secret", expresspay.WithSignatureFunc(requestsOnly))
+	h := c.NotificationHandler()
+	if err := h.Verify(testNotificationData, testNotificationSignature); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if err := h.Verify(testNotificationData, "00"+testNotificationSignature[2:]); !errors.Is(err, expresspay.ErrNotificationSignature) {
+		t.Errorf("bad signature: err = %v", err)
+	}
+}
+
+func TestNotificationHandlerRejectsMalformed(t *testing.T) {
+	h := &expresspay.NotificationHandler{}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notify", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d", w.Code)
+	}
+
+	_, err := h.Parse(notificationRequest("{", ""))
+	if !errors.Is(err, expresspay.ErrNotificationPayload) {
+		t.Errorf("broken JSON: err = %v", err)
+	}
+	_, err = h.Parse(notificationRequest("", ""))
+	if !errors.Is(err, expresspay.ErrNotificationPayload) {
+		t.Errorf("empty Data: err = %v", err)
+	}
+}
+
+func TestNotificationHandlerCallbackError(t *testing.T) {
+	h := &expresspay.NotificationHandler{
+		Secret: "secret",
+		OnNewPayment: func(ctx context.Context, n *expresspay.Notification) error {
+			return errors.New("database down")
+		},
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, notificationRequest(testNotificationData, testNotificationSignature))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500 so that Express Pay retries", w.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package expresspay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/dizel-by/expresspay/internal/qrcode"
+)
+
+const (
+	DefaultQRSize = 256
+	qrQuietZone   = 4
+)
+
+var ErrQRNotText = errors.New("expresspay: QR code body is an image, not text")
+
+// IsImage reports whether the body is a base64-encoded image (ViewType base64) rather than the
+// payload text (ViewType text).
+func (r *QRCodeResponse) IsImage() bool {
+	data, err := r.imageBytes()
+	if err != nil {
+		return false
+	}
+	_, _, err = image.DecodeConfig(bytes.NewReader(data))
+	return err == nil
+}
+
+func (r *QRCodeResponse) imageBytes() ([]byte, error) {
+	body := strings.TrimSpace(r.QrCodeBody)
+	if i := strings.Index(body, ";base64,"); i >= 0 && strings.HasPrefix(body, "data:") {
+		body = body[i+len(";base64,"):]
+	}
+	return base64.StdEncoding.DecodeString(body)
+}
+
+// Image decodes a base64 body, or renders a text body locally at DefaultQRSize.
+func (r *QRCodeResponse) Image() (image.Image, error) {
+	if r.IsImage() {
+		data, _ := r.imageBytes()
+		img, _, err := image.Decode(bytes.NewReader(data))
+		return img, err
+	}
+	return RenderQR(r.QrCodeBody, DefaultQRSize)
+}
+
+// PNG returns the image as PNG bytes, re-encoding non-PNG images and rendering text bodies.
+func (r *QRCodeResponse) PNG() ([]byte, error) {
+	if r.IsImage() {
+		data, _ := r.imageBytes()
+		if bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+			return data, nil
+		}
+	}
+	img, err := r.Image()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *QRCodeResponse) WriteTo(w io.Writer) (int64, error) {
+	data, err := r.PNG()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Render draws a text body as a QR code of roughly size×size pixels.
+func (r *QRCodeResponse) Render(size int) (image.Image, error) {
+	if r.IsImage() {
+		return nil, ErrQRNotText
+	}
+	return RenderQR(r.QrCodeBody, size)
+}
+
+func (r *QRCodeResponse) SVG() (string, error) {
+	if r.IsImage() {
+		return "", ErrQRNotText
+	}
+	return QRSVG(r.QrCodeBody)
+}
+
+func (r *QRCodeResponse) ASCII() (string, error) {
+	if r.IsImage() {
+		return "", ErrQRNotText
+	}
+	return QRASCII(r.QrCodeBody)
+}
+
+func encodeQR(text string) (*qrcode.Code, error) {
+	code, err := qrcode.Encode([]byte(text), qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("expresspay: %w", err)
+	}
+	return code, nil
+}
+
+// RenderQR encodes text locally. Modules are scaled by a whole number of pixels, so the image
+// may be slightly smaller than size; it is never smaller than one pixel per module.
+func RenderQR(text string, size int) (image.Image, error) {
+	code, err := encodeQR(text)
+	if err != nil {
+		return nil, err
+	}
+	modules := code.Size + 2*qrQuietZone
+	scale := max(size/modules, 1)
+	img := image.NewPaletted(image.Rect(0, 0, modules*scale, modules*scale), color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Dark(x, y) {
+				continue
+			}
+			px, py := (x+qrQuietZone)*scale, (y+qrQuietZone)*scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(px+dx, py+dy, 1)
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// QRSVG encodes text as a scalable SVG document, one unit per module.
+func QRSVG(text string) (string, error) {
+	code, err := encodeQR(text)
+	if err != nil {
+		return "", err
+	}
+	modules := code.Size + 2*qrQuietZone
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Dark(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String(), nil
+}
+
+// QRASCII encodes text as terminal art, two module rows per line using half-block characters.
+// It is drawn dark-on-light, so it scans on terminals with a light foreground too.
+func QRASCII(text string) (string, error) {
+	code, err := encodeQR(text)
+	if err != nil {
+		return "", err
+	}
+	light := func(x, y int) bool { return !code.Dark(x, y) }
+	var b strings.Builder
+	for y := -qrQuietZone; y < code.Size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < code.Size+qrQuietZone; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,180 @@
+package expresspay
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointFamily groups API paths that share a rate limit and concurrency budget.
+type EndpointFamily string
+
+const (
+	FamilyInvoices     EndpointFamily = "invoices"
+	FamilyPayments     EndpointFamily = "payments"
+	FamilyCardInvoices EndpointFamily = "cardinvoices"
+	FamilyQRCode       EndpointFamily = "qrcode"
+)
+
+// endpointFamily maps a request path to its family. Web invoices count as invoices and web card
+// invoices as card invoices.
+func endpointFamily(path string) EndpointFamily {
+	segment, _, _ := strings.Cut(path, "/")
+	switch segment {
+	case "invoices", "web_invoices":
+		return FamilyInvoices
+	case "cardinvoices", "web_cardinvoices":
+		return FamilyCardInvoices
+	case "payments":
+		return FamilyPayments
+	case "qrcode":
+		return FamilyQRCode
+	}
+	return EndpointFamily(segment)
+}
+
+// WithRateLimit allows rps requests per second on average, with bursts of up to burst requests,
+// across all endpoints. Every attempt counts, including retries.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter().global.bucket = newTokenBucket(rps, burst)
+	}
+}
+
+// WithMaxConcurrent caps the number of requests in flight across all endpoints. A request holds
+// its slot until the response headers arrive; reading the body, including the items of a
+// streamed InvoicesSeq or PaymentsSeq, does not count.
+func WithMaxConcurrent(n int) Option {
+	return func(c *Client) {
+		c.limiter().global.slots = newSlots(n)
+	}
+}
+
+// WithEndpointRateLimit is WithRateLimit for one endpoint family. Requests must pass both the
+// family and the global limit.
+func WithEndpointRateLimit(family EndpointFamily, rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter().family(family).bucket = newTokenBucket(rps, burst)
+	}
+}
+
+func WithEndpointMaxConcurrent(family EndpointFamily, n int) Option {
+	return func(c *Client) {
+		c.limiter().family(family).slots = newSlots(n)
+	}
+}
+
+type requestLimiter struct {
+	global   budget
+	families map[EndpointFamily]*budget
+}
+
+type budget struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+func (c *Client) limiter() *requestLimiter {
+	if c.limits == nil {
+		c.limits = &requestLimiter{}
+	}
+	return c.limits
+}
+
+func (l *requestLimiter) family(f EndpointFamily) *budget {
+	if l.families == nil {
+		l.families = map[EndpointFamily]*budget{}
+	}
+	b, ok := l.families[f]
+	if !ok {
+		b = &budget{}
+		l.families[f] = b
+	}
+	return b
+}
+
+// acquire waits for the family budget of path and then the global one. The returned function
+// gives back the concurrency slots.
+func (l *requestLimiter) acquire(ctx context.Context, path string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	var held []chan struct{}
+	release := func() {
+		for _, s := range held {
+			<-s
+		}
+	}
+	for _, b := range []*budget{l.families[endpointFamily(path)], &l.global} {
+		if b == nil {
+			continue
+		}
+		if b.bucket != nil {
+			if err := b.bucket.wait(ctx); err != nil {
+				release()
+				return nil, err
+			}
+		}
+		if b.slots != nil {
+			select {
+			case b.slots <- struct{}{}:
+				held = append(held, b.slots)
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return release, nil
+}
+
+func newSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait reserves a token, sleeping until it is available. A wait cut short by ctx returns the
+// token, but only to callers that arrive later: waiters queued behind this one computed their
+// delays against the debt when they reserved and keep sleeping for the full delay, so the rate
+// after a cancellation can briefly fall below rps.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := sleepCtx(ctx, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package expresspay_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+// Calling the client from inside an InvoicesSeq loop must not wait for the slot held by the
+// list request.
+func TestMaxConcurrentNestedCall(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client(expresspay.WithMaxConcurrent(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, a := range []string{"A-1", "A-2"} {
+		if _, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{AccountNo: a, Amount: expresspay.BYN(100)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := 0
+	for inv, err := range c.InvoicesSeq(ctx, expresspay.ListInvoicesParams{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		no, _ := inv.InvoiceNo.Int64()
+		if _, err := c.GetInvoiceStatus(ctx, int(no)); err != nil {
+			t.Fatalf("nested call: %v", err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("%d invoices", n)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv, _ := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		cur := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		inFlight.Add(-1)
+		w.Write([]byte(`{"Status": 1}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithMaxConcurrent(2))
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetInvoiceStatus(context.Background(), 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency %d, want 2", p)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Status": 1}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithRateLimit(50, 1))
+	start := time.Now()
+	for range 5 {
+		if _, err := c.GetInvoiceStatus(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One token up front, then one every 20ms.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("5 requests at 50 rps took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	slow := expresspay.NewClient(srv.URL, "token", "", expresspay.WithRateLimit(0.1, 1))
+	slow.GetInvoiceStatus(context.Background(), 1)
+	before := hits.Load()
+	if _, err := slow.GetInvoiceStatus(ctx, 1); err == nil {
+		t.Error("request admitted past the deadline")
+	}
+	if hits.Load() != before {
+		t.Error("rate-limited request reached the server")
+	}
+}
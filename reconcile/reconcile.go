@@ -0,0 +1,263 @@
+// Package reconcile matches Express Pay payments to invoices. The API links the two only through
+// AccountNo, so payments are assigned by account number and amount.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+type Outcome string
+
+const (
+	OutcomeUnpaid   Outcome = "unpaid"
+	OutcomePartial  Outcome = "partial"
+	OutcomePaid     Outcome = "paid"
+	OutcomeOverpaid Outcome = "overpaid"
+)
+
+type Match struct {
+	Invoice  expresspay.Invoice
+	Payments []expresspay.Payment
+	Paid     expresspay.Money
+	Outcome  Outcome
+
+	// StatusMismatch is set when the invoice status reported by the API disagrees with the
+	// matched payments, e.g. status Paid with nothing found or PartiallyPaid with the full amount.
+	StatusMismatch bool
+}
+
+// Duplicate is a payment for an invoice that was already fully paid by an earlier payment of the
+// same amount.
+type Duplicate struct {
+	Payment   expresspay.Payment
+	InvoiceNo string
+}
+
+// Totals are per currency. Paid and Payments include orphaned payments; Outstanding leaves out
+// expired and canceled invoices.
+type Totals struct {
+	Currency    string
+	Invoices    int
+	Payments    int
+	Invoiced    expresspay.Money
+	Paid        expresspay.Money
+	Outstanding expresspay.Money
+	Overpaid    expresspay.Money
+	Orphaned    expresspay.Money
+}
+
+type Report struct {
+	From, To   time.Time
+	Matches    []Match
+	Orphans    []expresspay.Payment
+	Duplicates []Duplicate
+	Totals     map[string]*Totals
+}
+
+func (r *Report) ByOutcome(o Outcome) []Match {
+	var out []Match
+	for _, m := range r.Matches {
+		if m.Outcome == o {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Run lists invoices and payments created between from and to and reconciles them. Payments for
+// invoices created before from show up as orphans, so pick a period that starts early enough.
+func Run(ctx context.Context, c *expresspay.Client, from, to time.Time) (*Report, error) {
+	var invoices []expresspay.Invoice
+	for inv, err := range c.InvoicesSeq(ctx, expresspay.ListInvoicesParams{From: from, To: to}) {
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+	var payments []expresspay.Payment
+	for p, err := range c.PaymentsSeq(ctx, expresspay.ListPaymentsParams{From: from, To: to}) {
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	r, err := Reconcile(invoices, payments)
+	if err != nil {
+		return nil, err
+	}
+	r.From, r.To = from, to
+	return r, nil
+}
+
+type slot struct {
+	match *Match
+	due   expresspay.Money
+}
+
+// Reconcile matches payments to invoices in order of creation. Each payment goes to the oldest
+// invoice of the same account and currency whose outstanding amount it settles exactly, then to
+// the oldest invoice still owing anything. A payment for an account whose invoices are all settled
+// is a duplicate when it equals an invoice amount and an overpayment of the newest invoice
+// otherwise; a payment for an unknown account is an orphan. The only error is an amount
+// overflowing expresspay.Money (ErrMoneyOverflow).
+func Reconcile(invoices []expresspay.Invoice, payments []expresspay.Payment) (*Report, error) {
+	r := &Report{
+		Matches: make([]Match, len(invoices)),
+		Totals:  map[string]*Totals{},
+	}
+	for i, inv := range invoices {
+		r.Matches[i] = Match{Invoice: inv, Paid: expresspay.NewMoney(0, inv.Amount.Currency)}
+	}
+
+	byAccount := map[string][]*slot{}
+	for i := range r.Matches {
+		m := &r.Matches[i]
+		byAccount[m.Invoice.AccountNo] = append(byAccount[m.Invoice.AccountNo], &slot{match: m, due: m.Invoice.Amount})
+	}
+	for _, slots := range byAccount {
+		slices.SortStableFunc(slots, func(a, b *slot) int {
+			return a.match.Invoice.Created.Compare(b.match.Invoice.Created.Time)
+		})
+	}
+
+	payments = slices.Clone(payments)
+	slices.SortStableFunc(payments, func(a, b expresspay.Payment) int {
+		return a.Created.Compare(b.Created.Time)
+	})
+
+	for _, p := range payments {
+		t := r.totals(p.Amount.Currency)
+		t.Payments++
+		if err := add(&t.Paid, p.Amount); err != nil {
+			return nil, fmt.Errorf("reconcile: payment %s: %w", p.PaymentNo, err)
+		}
+
+		var candidates []*slot
+		for _, s := range byAccount[p.AccountNo] {
+			if s.match.Invoice.Amount.Currency == p.Amount.Currency {
+				candidates = append(candidates, s)
+			}
+		}
+		if len(candidates) == 0 {
+			r.Orphans = append(r.Orphans, p)
+			if err := add(&t.Orphaned, p.Amount); err != nil {
+				return nil, fmt.Errorf("reconcile: payment %s: %w", p.PaymentNo, err)
+			}
+			continue
+		}
+
+		target := pick(candidates, func(s *slot) bool { return s.due.Equal(p.Amount) })
+		if target == nil {
+			target = pick(candidates, func(s *slot) bool { return s.due.Minor > 0 })
+		}
+		if target == nil {
+			if dup := pick(candidates, func(s *slot) bool { return s.match.Invoice.Amount.Equal(p.Amount) }); dup != nil {
+				target = dup
+				r.Duplicates = append(r.Duplicates, Duplicate{Payment: p, InvoiceNo: dup.match.Invoice.InvoiceNo.String()})
+			} else {
+				target = candidates[len(candidates)-1]
+			}
+		}
+		due, err := target.due.Sub(p.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: payment %s: %w", p.PaymentNo, err)
+		}
+		target.due = due
+		target.match.Payments = append(target.match.Payments, p)
+		if err := add(&target.match.Paid, p.Amount); err != nil {
+			return nil, fmt.Errorf("reconcile: payment %s: %w", p.PaymentNo, err)
+		}
+	}
+
+	for i := range r.Matches {
+		m := &r.Matches[i]
+		inv := m.Invoice
+		t := r.totals(inv.Amount.Currency)
+		t.Invoices++
+		if err := add(&t.Invoiced, inv.Amount); err != nil {
+			return nil, fmt.Errorf("reconcile: invoice %s: %w", inv.InvoiceNo, err)
+		}
+		diff, err := m.Paid.Sub(inv.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: invoice %s: %w", inv.InvoiceNo, err)
+		}
+		switch {
+		case m.Paid.IsZero():
+			m.Outcome = OutcomeUnpaid
+		case diff.IsNegative():
+			m.Outcome = OutcomePartial
+		case diff.IsZero():
+			m.Outcome = OutcomePaid
+		default:
+			m.Outcome = OutcomeOverpaid
+			err = add(&t.Overpaid, diff)
+		}
+		if diff.IsNegative() && inv.Status != expresspay.InvoiceStatusCanceled && inv.Status != expresspay.InvoiceStatusExpired {
+			var owed expresspay.Money
+			if owed, err = inv.Amount.Sub(m.Paid); err == nil {
+				err = add(&t.Outstanding, owed)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: invoice %s: %w", inv.InvoiceNo, err)
+		}
+		m.StatusMismatch = statusMismatch(inv.Status, m.Outcome)
+	}
+	return r, nil
+}
+
+// add sets *dst to *dst + m.
+func add(dst *expresspay.Money, m expresspay.Money) error {
+	sum, err := dst.Add(m)
+	if err != nil {
+		return err
+	}
+	*dst = sum
+	return nil
+}
+
+func pick(slots []*slot, ok func(*slot) bool) *slot {
+	for _, s := range slots {
+		if ok(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+func statusMismatch(status expresspay.InvoiceStatus, o Outcome) bool {
+	switch {
+	case status.IsPaid():
+		return o == OutcomeUnpaid || o == OutcomePartial
+	case status == expresspay.InvoiceStatusPartiallyPaid:
+		return o != OutcomePartial
+	case status == expresspay.InvoiceStatusPendingPayment:
+		return o != OutcomeUnpaid
+	}
+	return false
+}
+
+func (r *Report) totals(currency string) *Totals {
+	t, ok := r.Totals[currency]
+	if !ok {
+		zero := expresspay.NewMoney(0, currency)
+		t = &Totals{Currency: currency, Invoiced: zero, Paid: zero, Outstanding: zero, Overpaid: zero, Orphaned: zero}
+		r.Totals[currency] = t
+	}
+	return t
+}
+
+// Currencies returns the currency codes present in Totals in a stable order.
+func (r *Report) Currencies() []string {
+	keys := make([]string, 0, len(r.Totals))
+	for k := range r.Totals {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
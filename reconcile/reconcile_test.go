@@ -0,0 +1,124 @@
+package reconcile_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/reconcile"
+)
+
+var day = time.Date(2024, 3, 1, 0, 0, 0, 0, expresspay.Minsk)
+
+func invoice(no int, account string, amount expresspay.Money, status expresspay.InvoiceStatus, hour int) expresspay.Invoice {
+	return expresspay.Invoice{
+		InvoiceNo: json.Number(strconv.Itoa(no)),
+		AccountNo: account,
+		Status:    status,
+		Created:   expresspay.Time{Time: day.Add(time.Duration(hour) * time.Hour)},
+		Amount:    amount,
+	}
+}
+
+func payment(no int, account string, amount expresspay.Money, hour int) expresspay.Payment {
+	return expresspay.Payment{
+		PaymentNo: json.Number(strconv.Itoa(no)),
+		AccountNo: account,
+		Created:   expresspay.Time{Time: day.Add(time.Duration(hour) * time.Hour)},
+		Amount:    amount,
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	invoices := []expresspay.Invoice{
+		invoice(1, "paid", expresspay.BYN(1000), expresspay.InvoiceStatusPaid, 1),
+		invoice(2, "partial", expresspay.BYN(1000), expresspay.InvoiceStatusPartiallyPaid, 1),
+		invoice(3, "over", expresspay.BYN(500), expresspay.InvoiceStatusPaid, 1),
+		invoice(4, "unpaid", expresspay.BYN(700), expresspay.InvoiceStatusPaid, 1),
+		invoice(5, "expired", expresspay.BYN(300), expresspay.InvoiceStatusExpired, 1),
+		invoice(6, "usd", expresspay.USD(200), expresspay.InvoiceStatusPaid, 1),
+	}
+	payments := []expresspay.Payment{
+		payment(1, "paid", expresspay.BYN(1000), 2),
+		payment(2, "partial", expresspay.BYN(400), 2),
+		payment(3, "over", expresspay.BYN(500), 2),
+		payment(4, "over", expresspay.BYN(500), 3),
+		payment(5, "nobody", expresspay.BYN(100), 2),
+		payment(6, "usd", expresspay.USD(200), 2),
+	}
+	r, err := reconcile.Reconcile(invoices, payments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []reconcile.Outcome{
+		reconcile.OutcomePaid, reconcile.OutcomePartial, reconcile.OutcomeOverpaid,
+		reconcile.OutcomeUnpaid, reconcile.OutcomeUnpaid, reconcile.OutcomePaid,
+	}
+	for i, m := range r.Matches {
+		if m.Outcome != want[i] {
+			t.Errorf("invoice %s: outcome %s, want %s", m.Invoice.InvoiceNo, m.Outcome, want[i])
+		}
+		if m.StatusMismatch != (i == 3) {
+			t.Errorf("invoice %s: StatusMismatch = %v", m.Invoice.InvoiceNo, m.StatusMismatch)
+		}
+	}
+	if len(r.Orphans) != 1 || r.Orphans[0].PaymentNo != "5" {
+		t.Errorf("orphans %+v", r.Orphans)
+	}
+	if len(r.Duplicates) != 1 || r.Duplicates[0].Payment.PaymentNo != "4" || r.Duplicates[0].InvoiceNo != "3" {
+		t.Errorf("duplicates %+v", r.Duplicates)
+	}
+
+	byn := r.Totals[expresspay.CurrencyBYN]
+	checks := []struct {
+		name      string
+		got, want expresspay.Money
+	}{
+		{"invoiced", byn.Invoiced, expresspay.BYN(3500)},
+		{"paid", byn.Paid, expresspay.BYN(2500)},
+		{"outstanding", byn.Outstanding, expresspay.BYN(1300)},
+		{"overpaid", byn.Overpaid, expresspay.BYN(500)},
+		{"orphaned", byn.Orphaned, expresspay.BYN(100)},
+		{"usd paid", r.Totals[expresspay.CurrencyUSD].Paid, expresspay.USD(200)},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if byn.Invoices != 5 || byn.Payments != 5 {
+		t.Errorf("%d invoices and %d payments", byn.Invoices, byn.Payments)
+	}
+}
+
+func TestReconcileExactAmountFirst(t *testing.T) {
+	invoices := []expresspay.Invoice{
+		invoice(1, "A", expresspay.BYN(1000), expresspay.InvoiceStatusPendingPayment, 1),
+		invoice(2, "A", expresspay.BYN(250), expresspay.InvoiceStatusPaid, 2),
+	}
+	r, err := reconcile.Reconcile(invoices, []expresspay.Payment{payment(1, "A", expresspay.BYN(250), 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Matches[0].Outcome != reconcile.OutcomeUnpaid || r.Matches[1].Outcome != reconcile.OutcomePaid {
+		t.Errorf("outcomes %s, %s", r.Matches[0].Outcome, r.Matches[1].Outcome)
+	}
+	if r.Matches[0].StatusMismatch || r.Matches[1].StatusMismatch {
+		t.Error("unexpected status mismatch")
+	}
+}
+
+func TestReconcileOverflow(t *testing.T) {
+	payments := []expresspay.Payment{
+		payment(1, "A", expresspay.BYN(math.MaxInt64), 1),
+		payment(2, "B", expresspay.BYN(1), 2),
+	}
+	if _, err := reconcile.Reconcile(nil, payments); !errors.Is(err, expresspay.ErrMoneyOverflow) {
+		t.Errorf("err = %v", err)
+	}
+}
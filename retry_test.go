@@ -0,0 +1,205 @@
+package expresspay_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+var fastRetry = expresspay.WithRetry(expresspay.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+func countingServer(t *testing.T, handler func(n int32, w http.ResponseWriter, r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(hits.Add(1), w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetryIdempotentOnServerError(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Status": 1}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry)
+	resp, err := c.GetInvoiceStatus(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != expresspay.InvoiceStatusPendingPayment || hits.Load() != 3 {
+		t.Errorf("status %v after %d attempts", resp.Status, hits.Load())
+	}
+}
+
+func TestNoRetryOfSentPost(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry, expresspay.WithoutValidation())
+	_, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if hits.Load() != 1 {
+		t.Errorf("POST sent %d times, want 1", hits.Load())
+	}
+}
+
+// A connection dropped after the request was written must not be retried for POST: the API
+// may have created the invoice.
+func TestNoRetryOfPostAfterConnectionLoss(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry, expresspay.WithoutValidation())
+	_, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if hits.Load() != 1 {
+		t.Errorf("POST sent %d times, want 1", hits.Load())
+	}
+}
+
+// A POST that never reached the server is safe to repeat.
+func TestRetryOfUnsentPost(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"InvoiceNo": 5}`))
+	})
+	var dials atomic.Int32
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if dials.Add(1) == 1 {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry, expresspay.WithoutValidation(),
+		expresspay.WithHTTPClient(&http.Client{Transport: transport}))
+	resp, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.InvoiceNo != "5" || dials.Load() != 2 || hits.Load() != 1 {
+		t.Errorf("invoice %s after %d dials and %d requests", resp.InvoiceNo, dials.Load(), hits.Load())
+	}
+}
+
+func TestRetryHonorsContext(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.GetInvoiceStatus(ctx, 1); err == nil {
+		t.Fatal("want error")
+	}
+	if hits.Load() != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("%d attempts in %v; a Retry-After past the deadline should stop at once", hits.Load(), time.Since(start))
+	}
+}
+
+func TestRetryAfterCappedAtMaxBackoff(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n == 1 {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Status": 1}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithRetry(expresspay.RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.GetInvoiceStatus(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 || time.Since(start) > time.Second {
+		t.Errorf("%d attempts in %v; Retry-After should be capped at MaxBackoff", hits.Load(), time.Since(start))
+	}
+}
+
+// truncated answers with a body that breaks off after data.
+func truncated(w http.ResponseWriter, data string) {
+	w.Header().Set("Content-Length", "1000")
+	w.Write([]byte(data))
+}
+
+func TestRetryIdempotentOnBrokenBody(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n == 1 {
+			truncated(w, `{"Status": `)
+			return
+		}
+		w.Write([]byte(`{"Status": 3}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry)
+	resp, err := c.GetInvoiceStatus(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != expresspay.InvoiceStatusPaid || hits.Load() != 2 {
+		t.Errorf("status %v after %d attempts", resp.Status, hits.Load())
+	}
+}
+
+func TestNoRetryOfPostOnBrokenBody(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		truncated(w, `{"InvoiceNo": `)
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry, expresspay.WithoutValidation())
+	if _, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)}); err == nil {
+		t.Fatal("want error")
+	}
+	if hits.Load() != 1 {
+		t.Errorf("POST sent %d times, want 1", hits.Load())
+	}
+}
+
+// A list whose body breaks off is fetched again; items already yielded are not repeated.
+func TestRetryStreamOnBrokenBody(t *testing.T) {
+	const first = `{"InvoiceNo": 1, "Amount": "1,00", "Currency": 933}`
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		if n == 1 {
+			truncated(w, `{"Items": [`+first+`, {"InvoiceNo": 2, "Amount": "2,00", "Currency": 933}, {"Inv`)
+			return
+		}
+		w.Write([]byte(`{"Items": [` + first + `, {"InvoiceNo": 2, "Amount": "2,00", "Currency": 933}, {"InvoiceNo": 3, "Amount": "3,00", "Currency": 933}]}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "", fastRetry)
+	var got []string
+	for inv, err := range c.InvoicesSeq(context.Background(), expresspay.ListInvoicesParams{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, inv.InvoiceNo.String())
+	}
+	if fmt.Sprint(got) != "[1 2 3]" || hits.Load() != 2 {
+		t.Errorf("invoices %v after %d requests", got, hits.Load())
+	}
+}
@@ -0,0 +1,271 @@
+package expresspay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const DefaultListWindowDays = 30
+
+func WithListWindowDays(days int) Option {
+	return func(c *Client) {
+		if days > 0 {
+			c.ListWindowDays = days
+		}
+	}
+}
+
+// InvoicesSeq lists invoices like ListInvoices, but splits the From/To range into windows of
+// ListWindowDays days and decodes each response incrementally. Iteration stops after the first
+// error.
+func (c *Client) InvoicesSeq(ctx context.Context, p ListInvoicesParams) iter.Seq2[Invoice, error] {
+	return func(yield func(Invoice, error) bool) {
+		windows, err := c.listWindows(p.From, p.To)
+		if err != nil {
+			yield(Invoice{}, err)
+			return
+		}
+		var seen pageDedup
+		for _, w := range windows {
+			seen.nextPage()
+			wp := p
+			wp.From, wp.To = w.from, w.to
+			query, err := c.listInvoicesQuery(wp)
+			if err != nil {
+				yield(Invoice{}, err)
+				return
+			}
+			stopped := false
+			err = c.streamItems(ctx, "get-list-invoices", "invoices", query, func(dec *json.Decoder) error {
+				var inv Invoice
+				if err := dec.Decode(&inv); err != nil {
+					return err
+				}
+				inv.fillCurrency()
+				if seen.seen(invoiceKey(inv)) {
+					return nil
+				}
+				if !yield(inv, nil) {
+					stopped = true
+					return errStopIteration
+				}
+				return nil
+			})
+			if stopped {
+				return
+			}
+			if err != nil {
+				yield(Invoice{}, err)
+				return
+			}
+		}
+	}
+}
+
+// PaymentsSeq is the ListPayments counterpart of InvoicesSeq.
+func (c *Client) PaymentsSeq(ctx context.Context, p ListPaymentsParams) iter.Seq2[Payment, error] {
+	return func(yield func(Payment, error) bool) {
+		windows, err := c.listWindows(p.From, p.To)
+		if err != nil {
+			yield(Payment{}, err)
+			return
+		}
+		var seen pageDedup
+		for _, w := range windows {
+			seen.nextPage()
+			wp := p
+			wp.From, wp.To = w.from, w.to
+			query, err := c.listPaymentsQuery(wp)
+			if err != nil {
+				yield(Payment{}, err)
+				return
+			}
+			stopped := false
+			err = c.streamItems(ctx, "get-list-payments", "payments", query, func(dec *json.Decoder) error {
+				var pay Payment
+				if err := dec.Decode(&pay); err != nil {
+					return err
+				}
+				pay.fillCurrency()
+				if seen.seen(paymentKey(pay)) {
+					return nil
+				}
+				if !yield(pay, nil) {
+					stopped = true
+					return errStopIteration
+				}
+				return nil
+			})
+			if stopped {
+				return
+			}
+			if err != nil {
+				yield(Payment{}, err)
+				return
+			}
+		}
+	}
+}
+
+func invoiceKey(inv Invoice) string {
+	if inv.InvoiceNo != "" {
+		return inv.InvoiceNo.String()
+	}
+	return "card:" + inv.CardInvoiceNo.String()
+}
+
+func paymentKey(p Payment) string {
+	if p.PaymentNo != "" {
+		return p.PaymentNo.String()
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", p.AccountNo, p.Created, p.Amount, p.Currency)
+}
+
+// pageDedup drops items already returned for the previous window. Windows do not overlap, so a
+// duplicate can only straddle a window boundary, and remembering one page keeps memory bounded.
+type pageDedup struct {
+	prev, cur map[string]bool
+}
+
+func (d *pageDedup) nextPage() {
+	d.prev, d.cur = d.cur, map[string]bool{}
+}
+
+func (d *pageDedup) seen(key string) bool {
+	if d.prev[key] || d.cur[key] {
+		return true
+	}
+	d.cur[key] = true
+	return false
+}
+
+type listWindow struct {
+	from, to time.Time
+}
+
+// listWindows splits the inclusive date range into consecutive, non-overlapping windows of whole
+// Minsk days. An open start cannot be split and yields a single request; an open end means today.
+func (c *Client) listWindows(from, to time.Time) ([]listWindow, error) {
+	if from.IsZero() {
+		return []listWindow{{from: from, to: to}}, nil
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	start, end := startOfDay(from), startOfDay(to)
+	if end.Before(start) {
+		return nil, fmt.Errorf("expresspay: From %s is after To %s", formatDate(from), formatDate(to))
+	}
+	days := c.ListWindowDays
+	if days <= 0 {
+		days = DefaultListWindowDays
+	}
+	var windows []listWindow
+	for cur := start; !cur.After(end); cur = cur.AddDate(0, 0, days) {
+		last := cur.AddDate(0, 0, days-1)
+		if last.After(end) {
+			last = end
+		}
+		windows = append(windows, listWindow{from: cur, to: last})
+	}
+	return windows, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(Minsk)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Minsk)
+}
+
+var errStopIteration = errors.New("expresspay: iteration stopped")
+
+// streamItems performs a GET request and calls item for every element of the top-level Items
+// array, positioned so that the next dec.Decode reads exactly one element.
+func (c *Client) streamItems(ctx context.Context, action, path string, query url.Values, item func(dec *json.Decoder) error) error {
+	return c.exchange(ctx, action, http.MethodGet, path, query, nil, func(status int, body io.Reader) error {
+		dec := json.NewDecoder(body)
+		dec.UseNumber()
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		// Everything but Items is kept, so that an error envelope is recognized by
+		// parseAPIError just like in a buffered response.
+		fields := map[string]json.RawMessage{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := tok.(string)
+			if key != "Items" {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					return err
+				}
+				fields[key] = raw
+				continue
+			}
+			if err := envelopeError(status, fields); err != nil {
+				return err
+			}
+			if err := streamArray(dec, item); err != nil {
+				return err
+			}
+		}
+		return envelopeError(status, fields)
+	})
+}
+
+// envelopeError returns the API error described by the top-level fields of a streamed response,
+// with Raw holding them re-encoded.
+func envelopeError(status int, fields map[string]json.RawMessage) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if apiErr := parseAPIError(data); apiErr != nil {
+		apiErr.HTTPStatus = status
+		return apiErr
+	}
+	return nil
+}
+
+func streamArray(dec *json.Decoder, item func(dec *json.Decoder) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expresspay: expected Items array, got %v", tok)
+	}
+	for dec.More() {
+		if err := item(dec); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expresspay: expected %v, got %v", want, tok)
+	}
+	return nil
+}
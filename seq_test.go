@@ -0,0 +1,123 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+// windowServer answers every list request for day d of January 2024 with invoices d and d+1, so
+// each window repeats the last invoice of the one before.
+func windowServer(t *testing.T, requests *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from := r.URL.Query().Get("From")
+		*requests = append(*requests, from)
+		day, _ := time.Parse(expresspay.DateLayout, from)
+		d := day.Day()
+		fmt.Fprintf(w, `{"Items": [{"InvoiceNo": %d, "Amount": "1,00", "Currency": 933}, {"InvoiceNo": %d, "Amount": "1,00", "Currency": 933}]}`, d, d+1)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInvoicesSeqSkipsBoundaryDuplicates(t *testing.T) {
+	var requests []string
+	srv := windowServer(t, &requests)
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithListWindowDays(1))
+
+	p := expresspay.ListInvoicesParams{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, expresspay.Minsk),
+		To:   time.Date(2024, 1, 4, 0, 0, 0, 0, expresspay.Minsk),
+	}
+	var got []string
+	for inv, err := range c.InvoicesSeq(context.Background(), p) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, inv.InvoiceNo.String())
+		if !inv.Amount.Equal(expresspay.BYN(100)) {
+			t.Errorf("invoice %s amount %+v", inv.InvoiceNo, inv.Amount)
+		}
+	}
+	if strings.Join(got, ",") != "1,2,3,4,5" {
+		t.Errorf("invoices %v", got)
+	}
+	if strings.Join(requests, ",") != "20240101,20240102,20240103,20240104" {
+		t.Errorf("windows %v", requests)
+	}
+}
+
+func TestInvoicesSeqStopsOnBreak(t *testing.T) {
+	var requests []string
+	srv := windowServer(t, &requests)
+	c := expresspay.NewClient(srv.URL, "token", "", expresspay.WithListWindowDays(1))
+
+	p := expresspay.ListInvoicesParams{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, expresspay.Minsk),
+		To:   time.Date(2024, 1, 10, 0, 0, 0, 0, expresspay.Minsk),
+	}
+	n := 0
+	for range c.InvoicesSeq(context.Background(), p) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("%d requests after break, want 2", len(requests))
+	}
+}
+
+func TestInvoicesSeqError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Items": [{"InvoiceNo": 1}], "Error": {"Code": 7, "Msg": "boom"}}`))
+	}))
+	defer srv.Close()
+	c := expresspay.NewClient(srv.URL, "token", "")
+	var items, errs int
+	for _, err := range c.InvoicesSeq(context.Background(), expresspay.ListInvoicesParams{}) {
+		if err != nil {
+			errs++
+			continue
+		}
+		items++
+	}
+	if items != 1 || errs != 1 {
+		t.Errorf("%d items and %d errors", items, errs)
+	}
+}
+
+// A streamed list recognizes the same error envelopes as a buffered response and keeps the
+// body in Raw.
+func TestInvoicesSeqErrorEnvelope(t *testing.T) {
+	for _, body := range []string{
+		`{"Error": {"Code": 4, "Msg": "not found"}}`,
+		`{"ErrorCode": 4}`,
+		`{"ErrorCode": 4, "ErrorMessage": "not found", "Items": []}`,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		c := expresspay.NewClient(srv.URL, "token", "")
+		var got error
+		for _, err := range c.InvoicesSeq(context.Background(), expresspay.ListInvoicesParams{}) {
+			got = err
+		}
+		srv.Close()
+		var apiErr *expresspay.APIError
+		if !errors.As(got, &apiErr) || apiErr.APICode() != 4 || !errors.Is(got, expresspay.ErrInvoiceNotFound) {
+			t.Errorf("%s: err = %v", body, got)
+			continue
+		}
+		if !strings.Contains(apiErr.Raw, `4`) || !strings.HasPrefix(apiErr.Raw, "{") {
+			t.Errorf("%s: Raw = %s", body, apiErr.Raw)
+		}
+	}
+}
@@ -134,6 +134,17 @@ var signatureOrder = map[string][]string{
 		"expirationdate",
 		"returntype",
 	},
+	"notification": {
+		"data",
+	},
+	"add-web-invoice-response": {
+		"expresspayaccountnumber",
+		"expresspayinvoiceno",
+	},
+	"add-webcard-invoice-response": {
+		"expresspayaccountnumber",
+		"expresspayinvoiceno",
+	},
 }
 
 func DefaultSignature(action string, params map[string]string, secret string) (string, error) {
@@ -159,6 +170,26 @@ func DefaultSignature(action string, params map[string]string, secret string) (s
 	return strings.ToUpper(hex.EncodeToString(h.Sum(nil))), nil
 }
 
+func (c *Client) verifyResponseSignature(action string, params map[string]string, signature string) error {
+	if !c.VerifyResponseSignature {
+		return nil
+	}
+	return c.checkSignature(action, params, signature)
+}
+
+// checkSignature verifies a signature made by Express Pay. SignatureFunc only signs requests, so
+// custom implementations need not know the response actions: DefaultSignature is always used.
+func (c *Client) checkSignature(action string, params map[string]string, signature string) error {
+	expected, err := DefaultSignature(action, params, c.Secret)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(strings.ToUpper(signature))) {
+		return ErrResponseSignatureMismatch
+	}
+	return nil
+}
+
 func parseAPIError(data []byte) *APIError {
 	var envelope struct {
 		Error        *APIError   `json:"Error"`
@@ -169,10 +200,12 @@ func parseAPIError(data []byte) *APIError {
 		return nil
 	}
 	if envelope.Error != nil {
+		envelope.Error.Raw = string(data)
 		return envelope.Error
 	}
-	if envelope.ErrorMessage != "" {
-		return &APIError{ErrorCode: intFromNumber(envelope.ErrorCode), ErrorMessage: envelope.ErrorMessage}
+	// Card endpoints answer 200 with ErrorCode/ErrorMessage fields instead of an Error object.
+	if intFromNumber(envelope.ErrorCode) != 0 || envelope.ErrorMessage != "" {
+		return &APIError{ErrorCode: intFromNumber(envelope.ErrorCode), ErrorMessage: envelope.ErrorMessage, Raw: string(data)}
 	}
 	return nil
 }
@@ -0,0 +1,59 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+func webInvoiceRequest() expresspay.AddWebInvoiceRequest {
+	return expresspay.AddWebInvoiceRequest{
+		ServiceID:  "17",
+		AccountNo:  "order-1",
+		Amount:     expresspay.BYN(1500),
+		ReturnType: "json",
+		ReturnURL:  "https://shop.example/ok",
+		FailURL:    "https://shop.example/fail",
+	}
+}
+
+// A SignatureFunc written for the request actions only keeps working: responses are verified
+// with DefaultSignature.
+func TestResponseSignatureIgnoresCustomSignatureFunc(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	requestsOnly := func(action string, params map[string]string, secret string) (string, error) {
+		switch action {
+		case "add-web-invoice", "add-webcard-invoice":
+			return expresspay.DefaultSignature(action, params, secret)
+		}
+		return "", fmt.Errorf("unexpected action %s", action)
+	}
+	c := srv.Client(expresspay.WithSignatureFunc(requestsOnly))
+	if _, err := c.CreateWebInvoice(context.Background(), webInvoiceRequest()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResponseSignatureMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ExpressPayAccountNumber": "order-1", "ExpressPayInvoiceNo": 5, "Signature": "0123"}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := expresspay.NewClient(srv.URL, "token", "secret")
+	if _, err := c.CreateWebInvoice(ctx, webInvoiceRequest()); !errors.Is(err, expresspay.ErrResponseSignatureMismatch) {
+		t.Errorf("err = %v", err)
+	}
+	c = expresspay.NewClient(srv.URL, "token", "secret", expresspay.WithResponseSignatureVerification(false))
+	if _, err := c.CreateWebInvoice(ctx, webInvoiceRequest()); err != nil {
+		t.Errorf("verification off: %v", err)
+	}
+}
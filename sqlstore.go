@@ -0,0 +1,280 @@
+package expresspay
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLStore is a Store on top of database/sql. It uses only portable SQL, so it works with the
+// SQLite and PostgreSQL drivers among others; set Placeholder to DollarPlaceholder for drivers that
+// expect $1-style parameters. Times are stored as fixed-width UTC text, which sorts correctly.
+type SQLStore struct {
+	DB          *sql.DB
+	Placeholder func(n int) string
+}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{DB: db}
+}
+
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+var sqlStoreSchema = []string{
+	`CREATE TABLE IF NOT EXISTS expresspay_invoices (
+		kind VARCHAR(16) NOT NULL,
+		invoice_no VARCHAR(64) NOT NULL,
+		account_no VARCHAR(64) NOT NULL,
+		amount BIGINT NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		status VARCHAR(8) NOT NULL,
+		request TEXT NOT NULL,
+		created VARCHAR(40) NOT NULL,
+		updated VARCHAR(40) NOT NULL,
+		PRIMARY KEY (kind, invoice_no)
+	)`,
+	`CREATE INDEX IF NOT EXISTS expresspay_invoices_account ON expresspay_invoices (account_no)`,
+	`CREATE TABLE IF NOT EXISTS expresspay_invoice_status (
+		kind VARCHAR(16) NOT NULL,
+		invoice_no VARCHAR(64) NOT NULL,
+		status VARCHAR(8) NOT NULL,
+		changed_at VARCHAR(40) NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS expresspay_invoice_payments (
+		kind VARCHAR(16) NOT NULL,
+		invoice_no VARCHAR(64) NOT NULL,
+		payment_no VARCHAR(64) NOT NULL,
+		PRIMARY KEY (kind, invoice_no, payment_no)
+	)`,
+}
+
+// Migrate creates the tables if they do not exist.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	for _, stmt := range sqlStoreSchema {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query rewrites the ? placeholders of q for the configured driver.
+func (s *SQLStore) query(q string) string {
+	if s.Placeholder == nil {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString(s.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func sqlTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+func parseSQLTime(s string) (time.Time, error) {
+	return time.Parse(sqlTimeLayout, s)
+}
+
+// SaveInvoice replaces any stored record with the same kind and number, history and payments
+// included. The upsert is a delete and insert in one transaction because ON CONFLICT and MERGE
+// are not portable.
+func (s *SQLStore) SaveInvoice(ctx context.Context, rec InvoiceRecord) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"expresspay_invoice_payments", "expresspay_invoice_status", "expresspay_invoices"} {
+		_, err := tx.ExecContext(ctx, s.query(`DELETE FROM `+table+` WHERE kind = ? AND invoice_no = ?`), rec.Kind, rec.InvoiceNo)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, s.query(`INSERT INTO expresspay_invoices
+		(kind, invoice_no, account_no, amount, currency, status, request, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		rec.Kind, rec.InvoiceNo, rec.AccountNo, rec.Amount.Minor, rec.Amount.Currency, rec.Status,
+		string(rec.Request), sqlTime(rec.Created), sqlTime(rec.Updated))
+	if err != nil {
+		return err
+	}
+	for _, h := range rec.History {
+		if err := s.insertStatus(ctx, tx, rec.Kind, rec.InvoiceNo, h); err != nil {
+			return err
+		}
+	}
+	for _, p := range rec.Payments {
+		if err := s.insertPayment(ctx, tx, rec.Kind, rec.InvoiceNo, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) insertStatus(ctx context.Context, tx *sql.Tx, kind InvoiceKind, invoiceNo string, h StatusChange) error {
+	_, err := tx.ExecContext(ctx, s.query(`INSERT INTO expresspay_invoice_status (kind, invoice_no, status, changed_at) VALUES (?, ?, ?, ?)`),
+		kind, invoiceNo, h.Status, sqlTime(h.At))
+	return err
+}
+
+func (s *SQLStore) insertPayment(ctx context.Context, tx *sql.Tx, kind InvoiceKind, invoiceNo, paymentNo string) error {
+	_, err := tx.ExecContext(ctx, s.query(`INSERT INTO expresspay_invoice_payments (kind, invoice_no, payment_no) VALUES (?, ?, ?)`),
+		kind, invoiceNo, paymentNo)
+	return err
+}
+
+func (s *SQLStore) UpdateStatus(ctx context.Context, kind InvoiceKind, invoiceNo string, status InvoiceStatus, at time.Time) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var current string
+	err = tx.QueryRowContext(ctx, s.query(`SELECT status FROM expresspay_invoices WHERE kind = ? AND invoice_no = ?`), kind, invoiceNo).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && InvoiceStatus(current) == status) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, s.query(`UPDATE expresspay_invoices SET status = ?, updated = ? WHERE kind = ? AND invoice_no = ?`),
+		status, sqlTime(at), kind, invoiceNo)
+	if err != nil {
+		return err
+	}
+	if err := s.insertStatus(ctx, tx, kind, invoiceNo, StatusChange{Status: status, At: at}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) LinkPayment(ctx context.Context, kind InvoiceKind, invoiceNo, paymentNo string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var n int
+	err = tx.QueryRowContext(ctx, s.query(`SELECT
+		(SELECT COUNT(*) FROM expresspay_invoices WHERE kind = ? AND invoice_no = ?) -
+		(SELECT COUNT(*) FROM expresspay_invoice_payments WHERE kind = ? AND invoice_no = ? AND payment_no = ?)`),
+		kind, invoiceNo, kind, invoiceNo, paymentNo).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return nil
+	}
+	if err := s.insertPayment(ctx, tx, kind, invoiceNo, paymentNo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) Invoice(ctx context.Context, kind InvoiceKind, invoiceNo string) (*InvoiceRecord, error) {
+	recs, err := s.load(ctx, `kind = ? AND invoice_no = ?`, kind, invoiceNo)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, ErrNotStored
+	}
+	return &recs[0], nil
+}
+
+func (s *SQLStore) InvoicesByAccount(ctx context.Context, accountNo string) ([]InvoiceRecord, error) {
+	return s.load(ctx, `account_no = ?`, accountNo)
+}
+
+func (s *SQLStore) load(ctx context.Context, where string, args ...any) ([]InvoiceRecord, error) {
+	rows, err := s.DB.QueryContext(ctx, s.query(`SELECT kind, invoice_no, account_no, amount, currency, status, request, created, updated
+		FROM expresspay_invoices WHERE `+where+` ORDER BY created`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var recs []InvoiceRecord
+	for rows.Next() {
+		var rec InvoiceRecord
+		var request, created, updated string
+		if err := rows.Scan(&rec.Kind, &rec.InvoiceNo, &rec.AccountNo, &rec.Amount.Minor, &rec.Amount.Currency,
+			&rec.Status, &request, &created, &updated); err != nil {
+			return nil, err
+		}
+		rec.Request = json.RawMessage(request)
+		if rec.Created, err = parseSQLTime(created); err != nil {
+			return nil, err
+		}
+		if rec.Updated, err = parseSQLTime(updated); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for i := range recs {
+		if err := s.loadChildren(ctx, &recs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
+
+func (s *SQLStore) loadChildren(ctx context.Context, rec *InvoiceRecord) error {
+	rows, err := s.DB.QueryContext(ctx, s.query(`SELECT status, changed_at FROM expresspay_invoice_status
+		WHERE kind = ? AND invoice_no = ? ORDER BY changed_at`), rec.Kind, rec.InvoiceNo)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var h StatusChange
+		var at string
+		if err := rows.Scan(&h.Status, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		if h.At, err = parseSQLTime(at); err != nil {
+			rows.Close()
+			return err
+		}
+		rec.History = append(rec.History, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = s.DB.QueryContext(ctx, s.query(`SELECT payment_no FROM expresspay_invoice_payments
+		WHERE kind = ? AND invoice_no = ?`), rec.Kind, rec.InvoiceNo)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return err
+		}
+		rec.Payments = append(rec.Payments, p)
+	}
+	return rows.Err()
+}
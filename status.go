@@ -0,0 +1,226 @@
+package expresspay
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// InvoiceStatus is the numeric invoice status code as sent by the API. Values that are not
+// listed below are kept verbatim.
+type InvoiceStatus string
+
+const (
+	InvoiceStatusPendingPayment  InvoiceStatus = "1"
+	InvoiceStatusExpired         InvoiceStatus = "2"
+	InvoiceStatusPaid            InvoiceStatus = "3"
+	InvoiceStatusPartiallyPaid   InvoiceStatus = "4"
+	InvoiceStatusCanceled        InvoiceStatus = "5"
+	InvoiceStatusPaidByBankCard  InvoiceStatus = "6"
+	InvoiceStatusPaymentReturned InvoiceStatus = "7"
+)
+
+var invoiceStatusNames = map[InvoiceStatus]string{
+	InvoiceStatusPendingPayment:  "PendingPayment",
+	InvoiceStatusExpired:         "Expired",
+	InvoiceStatusPaid:            "Paid",
+	InvoiceStatusPartiallyPaid:   "PartiallyPaid",
+	InvoiceStatusCanceled:        "Canceled",
+	InvoiceStatusPaidByBankCard:  "PaidByBankCard",
+	InvoiceStatusPaymentReturned: "PaymentReturned",
+}
+
+var invoiceStatusTransitions = map[InvoiceStatus][]InvoiceStatus{
+	InvoiceStatusPendingPayment: {
+		InvoiceStatusExpired,
+		InvoiceStatusPaid,
+		InvoiceStatusPartiallyPaid,
+		InvoiceStatusCanceled,
+		InvoiceStatusPaidByBankCard,
+	},
+	InvoiceStatusPartiallyPaid: {
+		InvoiceStatusExpired,
+		InvoiceStatusPaid,
+		InvoiceStatusCanceled,
+	},
+	InvoiceStatusPaid: {
+		InvoiceStatusPaymentReturned,
+	},
+	InvoiceStatusPaidByBankCard: {
+		InvoiceStatusPaymentReturned,
+	},
+}
+
+func (s InvoiceStatus) String() string {
+	if name, ok := invoiceStatusNames[s]; ok {
+		return name
+	}
+	if s == "" {
+		return "Unknown"
+	}
+	return "InvoiceStatus(" + string(s) + ")"
+}
+
+func (s InvoiceStatus) Code() int {
+	return intFromNumber(json.Number(s))
+}
+
+func (s InvoiceStatus) IsKnown() bool {
+	_, ok := invoiceStatusNames[s]
+	return ok
+}
+
+// IsFinal reports whether the invoice no longer accepts payments. A paid invoice can still move
+// to InvoiceStatusPaymentReturned when the payment is reversed.
+func (s InvoiceStatus) IsFinal() bool {
+	switch s {
+	case InvoiceStatusExpired, InvoiceStatusPaid, InvoiceStatusCanceled,
+		InvoiceStatusPaidByBankCard, InvoiceStatusPaymentReturned:
+		return true
+	}
+	return false
+}
+
+func (s InvoiceStatus) IsPaid() bool {
+	return s == InvoiceStatusPaid || s == InvoiceStatusPaidByBankCard
+}
+
+// CanTransitionTo reports whether the API may move an invoice from s to next. Unknown statuses
+// are not restricted.
+func (s InvoiceStatus) CanTransitionTo(next InvoiceStatus) bool {
+	if s == next {
+		return true
+	}
+	if !s.IsKnown() || !next.IsKnown() {
+		return true
+	}
+	for _, allowed := range invoiceStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s InvoiceStatus) MarshalJSON() ([]byte, error) {
+	return marshalStatusCode(string(s))
+}
+
+func (s *InvoiceStatus) UnmarshalJSON(data []byte) error {
+	code, err := unmarshalStatusCode(data)
+	*s = InvoiceStatus(code)
+	return err
+}
+
+func marshalStatusCode(code string) ([]byte, error) {
+	if code == "" {
+		return []byte("null"), nil
+	}
+	if isDigits(code) {
+		return []byte(code), nil
+	}
+	return json.Marshal(code)
+}
+
+// unmarshalStatusCode accepts a status code sent as a JSON number or string.
+func unmarshalStatusCode(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var v string
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	var n json.Number
+	err := json.Unmarshal(data, &n)
+	return string(n), err
+}
+
+// CardInvoiceStatus is the status of a card invoice as reported by GetCardInvoiceStatus. Card
+// invoices have their own codes, listed for the CardInvoiceStatus field in the Express Pay API
+// documentation; they do not line up with InvoiceStatus.
+type CardInvoiceStatus string
+
+const (
+	CardInvoiceStatusRegistered        CardInvoiceStatus = "100"
+	CardInvoiceStatusRegistrationError CardInvoiceStatus = "101"
+	CardInvoiceStatusHeld              CardInvoiceStatus = "102"
+	CardInvoiceStatusAuthorized        CardInvoiceStatus = "103"
+	CardInvoiceStatusCanceled          CardInvoiceStatus = "104"
+	CardInvoiceStatusRefunded          CardInvoiceStatus = "105"
+	CardInvoiceStatusACSPending        CardInvoiceStatus = "106"
+	CardInvoiceStatusDeclined          CardInvoiceStatus = "107"
+)
+
+var cardInvoiceStatusNames = map[CardInvoiceStatus]string{
+	CardInvoiceStatusRegistered:        "Registered",
+	CardInvoiceStatusRegistrationError: "RegistrationError",
+	CardInvoiceStatusHeld:              "Held",
+	CardInvoiceStatusAuthorized:        "Authorized",
+	CardInvoiceStatusCanceled:          "Canceled",
+	CardInvoiceStatusRefunded:          "Refunded",
+	CardInvoiceStatusACSPending:        "ACSPending",
+	CardInvoiceStatusDeclined:          "Declined",
+}
+
+// cardInvoiceStatusMapping gives the InvoiceStatus a card invoice status corresponds to.
+// RegistrationError and Declined have no counterpart.
+var cardInvoiceStatusMapping = map[CardInvoiceStatus]InvoiceStatus{
+	CardInvoiceStatusRegistered: InvoiceStatusPendingPayment,
+	CardInvoiceStatusHeld:       InvoiceStatusPendingPayment,
+	CardInvoiceStatusACSPending: InvoiceStatusPendingPayment,
+	CardInvoiceStatusAuthorized: InvoiceStatusPaidByBankCard,
+	CardInvoiceStatusCanceled:   InvoiceStatusCanceled,
+	CardInvoiceStatusRefunded:   InvoiceStatusPaymentReturned,
+}
+
+func (s CardInvoiceStatus) String() string {
+	if name, ok := cardInvoiceStatusNames[s]; ok {
+		return name
+	}
+	if s == "" {
+		return "Unknown"
+	}
+	return "CardInvoiceStatus(" + string(s) + ")"
+}
+
+func (s CardInvoiceStatus) Code() int {
+	return intFromNumber(json.Number(s))
+}
+
+func (s CardInvoiceStatus) IsKnown() bool {
+	_, ok := cardInvoiceStatusNames[s]
+	return ok
+}
+
+// IsFinal reports whether the card invoice has left the payment flow: paid, refunded, canceled,
+// declined or never registered.
+func (s CardInvoiceStatus) IsFinal() bool {
+	switch s {
+	case CardInvoiceStatusRegistrationError, CardInvoiceStatusAuthorized, CardInvoiceStatusCanceled,
+		CardInvoiceStatusRefunded, CardInvoiceStatusDeclined:
+		return true
+	}
+	return false
+}
+
+// IsPaid reports whether the full amount has been authorized. A held amount is not paid yet.
+func (s CardInvoiceStatus) IsPaid() bool {
+	return s == CardInvoiceStatusAuthorized
+}
+
+// InvoiceStatus maps s to the matching InvoiceStatus, or "" when there is none.
+func (s CardInvoiceStatus) InvoiceStatus() InvoiceStatus {
+	return cardInvoiceStatusMapping[s]
+}
+
+func (s CardInvoiceStatus) MarshalJSON() ([]byte, error) {
+	return marshalStatusCode(string(s))
+}
+
+func (s *CardInvoiceStatus) UnmarshalJSON(data []byte) error {
+	code, err := unmarshalStatusCode(data)
+	*s = CardInvoiceStatus(code)
+	return err
+}
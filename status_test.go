@@ -0,0 +1,147 @@
+package expresspay_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+)
+
+var allInvoiceStatuses = []expresspay.InvoiceStatus{
+	expresspay.InvoiceStatusPendingPayment,
+	expresspay.InvoiceStatusExpired,
+	expresspay.InvoiceStatusPaid,
+	expresspay.InvoiceStatusPartiallyPaid,
+	expresspay.InvoiceStatusCanceled,
+	expresspay.InvoiceStatusPaidByBankCard,
+	expresspay.InvoiceStatusPaymentReturned,
+}
+
+func TestInvoiceStatus(t *testing.T) {
+	tests := []struct {
+		status expresspay.InvoiceStatus
+		name   string
+		paid   bool
+		final  bool
+		next   []expresspay.InvoiceStatus
+	}{
+		{expresspay.InvoiceStatusPendingPayment, "PendingPayment", false, false, []expresspay.InvoiceStatus{
+			expresspay.InvoiceStatusExpired,
+			expresspay.InvoiceStatusPaid,
+			expresspay.InvoiceStatusPartiallyPaid,
+			expresspay.InvoiceStatusCanceled,
+			expresspay.InvoiceStatusPaidByBankCard,
+		}},
+		{expresspay.InvoiceStatusExpired, "Expired", false, true, nil},
+		{expresspay.InvoiceStatusPaid, "Paid", true, true, []expresspay.InvoiceStatus{expresspay.InvoiceStatusPaymentReturned}},
+		{expresspay.InvoiceStatusPartiallyPaid, "PartiallyPaid", false, false, []expresspay.InvoiceStatus{
+			expresspay.InvoiceStatusExpired,
+			expresspay.InvoiceStatusPaid,
+			expresspay.InvoiceStatusCanceled,
+		}},
+		{expresspay.InvoiceStatusCanceled, "Canceled", false, true, nil},
+		{expresspay.InvoiceStatusPaidByBankCard, "PaidByBankCard", true, true, []expresspay.InvoiceStatus{expresspay.InvoiceStatusPaymentReturned}},
+		{expresspay.InvoiceStatusPaymentReturned, "PaymentReturned", false, true, nil},
+	}
+	if len(tests) != len(allInvoiceStatuses) {
+		t.Fatalf("%d cases for %d statuses", len(tests), len(allInvoiceStatuses))
+	}
+	for _, tt := range tests {
+		s := tt.status
+		if s.String() != tt.name || !s.IsKnown() {
+			t.Errorf("%s: String %q, IsKnown %v", tt.name, s.String(), s.IsKnown())
+		}
+		if s.IsPaid() != tt.paid {
+			t.Errorf("%s: IsPaid = %v", tt.name, s.IsPaid())
+		}
+		if s.IsFinal() != tt.final {
+			t.Errorf("%s: IsFinal = %v", tt.name, s.IsFinal())
+		}
+		allowed := map[expresspay.InvoiceStatus]bool{s: true}
+		for _, next := range tt.next {
+			allowed[next] = true
+		}
+		for _, next := range allInvoiceStatuses {
+			if got := s.CanTransitionTo(next); got != allowed[next] {
+				t.Errorf("%s -> %s: CanTransitionTo = %v", tt.name, next, got)
+			}
+		}
+		if !s.CanTransitionTo("42") || !expresspay.InvoiceStatus("42").CanTransitionTo(s) {
+			t.Errorf("%s: transitions to or from an unknown status are restricted", tt.name)
+		}
+	}
+
+	unknown := expresspay.InvoiceStatus("42")
+	if unknown.IsKnown() || unknown.IsPaid() || unknown.IsFinal() || unknown.String() != "InvoiceStatus(42)" {
+		t.Errorf("unknown status %s", unknown)
+	}
+}
+
+func TestCardInvoiceStatus(t *testing.T) {
+	tests := []struct {
+		status  expresspay.CardInvoiceStatus
+		name    string
+		paid    bool
+		final   bool
+		invoice expresspay.InvoiceStatus
+	}{
+		{expresspay.CardInvoiceStatusRegistered, "Registered", false, false, expresspay.InvoiceStatusPendingPayment},
+		{expresspay.CardInvoiceStatusRegistrationError, "RegistrationError", false, true, ""},
+		{expresspay.CardInvoiceStatusHeld, "Held", false, false, expresspay.InvoiceStatusPendingPayment},
+		{expresspay.CardInvoiceStatusAuthorized, "Authorized", true, true, expresspay.InvoiceStatusPaidByBankCard},
+		{expresspay.CardInvoiceStatusCanceled, "Canceled", false, true, expresspay.InvoiceStatusCanceled},
+		{expresspay.CardInvoiceStatusRefunded, "Refunded", false, true, expresspay.InvoiceStatusPaymentReturned},
+		{expresspay.CardInvoiceStatusACSPending, "ACSPending", false, false, expresspay.InvoiceStatusPendingPayment},
+		{expresspay.CardInvoiceStatusDeclined, "Declined", false, true, ""},
+	}
+	for _, tt := range tests {
+		s := tt.status
+		if s.String() != tt.name || !s.IsKnown() {
+			t.Errorf("%s: String %q, IsKnown %v", tt.name, s.String(), s.IsKnown())
+		}
+		if s.IsPaid() != tt.paid {
+			t.Errorf("%s: IsPaid = %v", tt.name, s.IsPaid())
+		}
+		if s.IsFinal() != tt.final {
+			t.Errorf("%s: IsFinal = %v", tt.name, s.IsFinal())
+		}
+		if got := s.InvoiceStatus(); got != tt.invoice {
+			t.Errorf("%s: InvoiceStatus = %v, want %v", tt.name, got, tt.invoice)
+		}
+		// The mapped status must agree with the card status on whether the invoice is settled.
+		if got := s.InvoiceStatus(); got != "" && (got.IsPaid() != s.IsPaid() || got.IsFinal() != s.IsFinal()) {
+			t.Errorf("%s: maps to %s with different IsPaid/IsFinal", tt.name, got)
+		}
+	}
+
+	unknown := expresspay.CardInvoiceStatus("42")
+	if unknown.IsKnown() || unknown.IsPaid() || unknown.IsFinal() || unknown.InvoiceStatus() != "" {
+		t.Errorf("unknown status %s", unknown)
+	}
+	// ERIP status 3 is "Paid"; as a card code it means nothing.
+	if s := expresspay.CardInvoiceStatus(expresspay.InvoiceStatusPaid); s.IsPaid() || s.InvoiceStatus() != "" {
+		t.Errorf("ERIP code %v read as a card status", s)
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	var v struct {
+		Status     expresspay.InvoiceStatus
+		CardStatus expresspay.CardInvoiceStatus
+	}
+	for _, in := range []string{`{"Status": 3, "CardStatus": 103}`, `{"Status": "3", "CardStatus": "103"}`} {
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Status != expresspay.InvoiceStatusPaid || v.CardStatus != expresspay.CardInvoiceStatusAuthorized {
+			t.Errorf("%s: decoded %+v", in, v)
+		}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Status":3,"CardStatus":103}` {
+		t.Errorf("encoded %s", out)
+	}
+}
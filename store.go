@@ -0,0 +1,312 @@
+package expresspay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNotStored = errors.New("expresspay: invoice not in store")
+
+// InvoiceKind separates the two numbering schemes of the API: ERIP invoices (including web
+// invoices) and card invoices (including web card invoices).
+type InvoiceKind string
+
+const (
+	InvoiceKindERIP InvoiceKind = "invoice"
+	InvoiceKindCard InvoiceKind = "card"
+)
+
+type StatusChange struct {
+	Status InvoiceStatus `json:"status"`
+	At     time.Time     `json:"at"`
+}
+
+// InvoiceRecord is what a Store keeps per invoice. Request is the JSON encoding of the request
+// the invoice was created from.
+type InvoiceRecord struct {
+	Kind      InvoiceKind     `json:"kind"`
+	InvoiceNo string          `json:"invoice_no"`
+	AccountNo string          `json:"account_no"`
+	Amount    Money           `json:"amount"`
+	Status    InvoiceStatus   `json:"status"`
+	Request   json.RawMessage `json:"request"`
+	Created   time.Time       `json:"created"`
+	Updated   time.Time       `json:"updated"`
+	History   []StatusChange  `json:"history"`
+	Payments  []string        `json:"payments"`
+}
+
+// invoiceRecordJSON has the fields of InvoiceRecord without its JSON methods.
+type invoiceRecordJSON InvoiceRecord
+
+// MarshalJSON adds the amount's currency as a "currency" field: Money encodes only the number.
+func (r InvoiceRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		invoiceRecordJSON
+		Currency string `json:"currency"`
+	}{invoiceRecordJSON(r), r.Amount.Currency})
+}
+
+func (r *InvoiceRecord) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*invoiceRecordJSON
+		Currency string `json:"currency"`
+	}{invoiceRecordJSON: (*invoiceRecordJSON)(r)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.Amount.Currency = v.Currency
+	return nil
+}
+
+// Store is a ledger of the invoices created through a Client. SaveInvoice replaces a record with
+// the same kind and number. UpdateStatus and LinkPayment
+// ignore invoices the store does not hold, and UpdateStatus records history only when the status
+// actually changes. Invoice returns ErrNotStored for unknown invoices.
+type Store interface {
+	SaveInvoice(ctx context.Context, rec InvoiceRecord) error
+	UpdateStatus(ctx context.Context, kind InvoiceKind, invoiceNo string, status InvoiceStatus, at time.Time) error
+	LinkPayment(ctx context.Context, kind InvoiceKind, invoiceNo, paymentNo string) error
+	Invoice(ctx context.Context, kind InvoiceKind, invoiceNo string) (*InvoiceRecord, error)
+	InvoicesByAccount(ctx context.Context, accountNo string) ([]InvoiceRecord, error)
+}
+
+// WithStore records created invoices and the status changes seen by CancelInvoice,
+// ReverseCardInvoice, GetInvoice and the status calls. The API result is returned even when the
+// store fails; store errors are logged to the client's Logger, if any.
+func WithStore(s Store) Option {
+	return func(c *Client) {
+		c.Store = s
+	}
+}
+
+func (c *Client) storeInvoice(ctx context.Context, kind InvoiceKind, invoiceNo json.Number, accountNo string, amount Money, request any) {
+	if c.Store == nil || invoiceNo == "" {
+		return
+	}
+	req, err := json.Marshal(request)
+	if err != nil {
+		c.storeFailed(ctx, "save", err)
+		return
+	}
+	now := time.Now()
+	rec := InvoiceRecord{
+		Kind:      kind,
+		InvoiceNo: invoiceNo.String(),
+		AccountNo: accountNo,
+		Amount:    amount,
+		Status:    InvoiceStatusPendingPayment,
+		Request:   req,
+		Created:   now,
+		Updated:   now,
+		History:   []StatusChange{{Status: InvoiceStatusPendingPayment, At: now}},
+	}
+	if err := c.Store.SaveInvoice(ctx, rec); err != nil {
+		c.storeFailed(ctx, "save", err)
+	}
+}
+
+func (c *Client) storeStatus(ctx context.Context, kind InvoiceKind, invoiceNo int, status InvoiceStatus) {
+	if c.Store == nil || status == "" {
+		return
+	}
+	if err := c.Store.UpdateStatus(ctx, kind, fmt.Sprint(invoiceNo), status, time.Now()); err != nil {
+		c.storeFailed(ctx, "update status", err)
+	}
+}
+
+func (c *Client) storeFailed(ctx context.Context, op string, err error) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.LogAttrs(ctx, slog.LevelError, "expresspay store "+op+" failed", slog.String("error", err.Error()))
+}
+
+// applyStatus is the shared UpdateStatus logic of the built-in stores.
+func (r *InvoiceRecord) applyStatus(status InvoiceStatus, at time.Time) bool {
+	if r.Status == status {
+		return false
+	}
+	r.Status = status
+	r.Updated = at
+	r.History = append(r.History, StatusChange{Status: status, At: at})
+	return true
+}
+
+type fileStoreOp struct {
+	Op        string         `json:"op"`
+	Record    *InvoiceRecord `json:"record,omitempty"`
+	Kind      InvoiceKind    `json:"kind,omitempty"`
+	InvoiceNo string         `json:"invoice_no,omitempty"`
+	Status    InvoiceStatus  `json:"status,omitempty"`
+	At        time.Time      `json:"at"`
+	PaymentNo string         `json:"payment_no,omitempty"`
+}
+
+// FileStore is a Store backed by an append-only JSON Lines log, replayed into memory on open.
+// It suits a single process; use SQLStore when several processes share the ledger.
+type FileStore struct {
+	mu       sync.Mutex
+	f        *os.File
+	invoices map[string]*InvoiceRecord
+}
+
+// OpenFileStore replays the log at path, creating it if needed. A torn final line left by a
+// crash mid-write is cut off so later appends start on a fresh line.
+func OpenFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{f: f, invoices: map[string]*InvoiceRecord{}}
+	if err := s.replay(path); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) replay(path string) error {
+	r := bufio.NewReader(s.f)
+	var good int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				// Only the final line can be torn: drop it.
+				return s.f.Truncate(good)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var op fileStoreOp
+		if err := json.Unmarshal(data, &op); err != nil {
+			if _, peekErr := r.Peek(1); peekErr == io.EOF {
+				return s.f.Truncate(good)
+			}
+			return fmt.Errorf("expresspay: %s line %d: %w", path, line, err)
+		}
+		if op.Op == "save" && op.Record == nil {
+			return fmt.Errorf("expresspay: %s line %d: save without a record", path, line)
+		}
+		if key, rec := s.next(op); rec != nil {
+			s.invoices[key] = rec
+		}
+		good += int64(len(data))
+	}
+}
+
+func storeKey(kind InvoiceKind, invoiceNo string) string {
+	return string(kind) + ":" + invoiceNo
+}
+
+// next returns the record op leaves behind, as a copy, or nil when op changes nothing.
+func (s *FileStore) next(op fileStoreOp) (string, *InvoiceRecord) {
+	switch op.Op {
+	case "save":
+		return storeKey(op.Record.Kind, op.Record.InvoiceNo), op.Record.clone()
+	case "status":
+		key := storeKey(op.Kind, op.InvoiceNo)
+		if rec, ok := s.invoices[key]; ok {
+			rec = rec.clone()
+			if rec.applyStatus(op.Status, op.At) {
+				return key, rec
+			}
+		}
+	case "payment":
+		key := storeKey(op.Kind, op.InvoiceNo)
+		if rec, ok := s.invoices[key]; ok && !slices.Contains(rec.Payments, op.PaymentNo) {
+			rec = rec.clone()
+			rec.Payments = append(rec.Payments, op.PaymentNo)
+			return key, rec
+		}
+	}
+	return "", nil
+}
+
+// commit appends op to the log if it changes anything, and applies it to memory once the write
+// is on disk, so a failed write leaves the store as it was. Callers hold s.mu.
+func (s *FileStore) commit(op fileStoreOp) error {
+	key, rec := s.next(op)
+	if rec == nil {
+		return nil
+	}
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.invoices[key] = rec
+	return nil
+}
+
+func (s *FileStore) SaveInvoice(ctx context.Context, rec InvoiceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(fileStoreOp{Op: "save", Record: &rec})
+}
+
+func (s *FileStore) UpdateStatus(ctx context.Context, kind InvoiceKind, invoiceNo string, status InvoiceStatus, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(fileStoreOp{Op: "status", Kind: kind, InvoiceNo: invoiceNo, Status: status, At: at})
+}
+
+func (s *FileStore) LinkPayment(ctx context.Context, kind InvoiceKind, invoiceNo, paymentNo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(fileStoreOp{Op: "payment", Kind: kind, InvoiceNo: invoiceNo, PaymentNo: paymentNo})
+}
+
+func (s *FileStore) Invoice(ctx context.Context, kind InvoiceKind, invoiceNo string) (*InvoiceRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.invoices[storeKey(kind, invoiceNo)]
+	if !ok {
+		return nil, ErrNotStored
+	}
+	return rec.clone(), nil
+}
+
+func (s *FileStore) InvoicesByAccount(ctx context.Context, accountNo string) ([]InvoiceRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []InvoiceRecord
+	for _, rec := range s.invoices {
+		if rec.AccountNo == accountNo {
+			out = append(out, *rec.clone())
+		}
+	}
+	slices.SortFunc(out, func(a, b InvoiceRecord) int { return a.Created.Compare(b.Created) })
+	return out, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+func (r *InvoiceRecord) clone() *InvoiceRecord {
+	c := *r
+	c.Request = slices.Clone(r.Request)
+	c.History = slices.Clone(r.History)
+	c.Payments = slices.Clone(r.Payments)
+	return &c
+}
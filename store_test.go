@@ -0,0 +1,232 @@
+package expresspay_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+func storeRecord(no string) expresspay.InvoiceRecord {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	return expresspay.InvoiceRecord{
+		Kind:      expresspay.InvoiceKindERIP,
+		InvoiceNo: no,
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(1000),
+		Status:    expresspay.InvoiceStatusPendingPayment,
+		Request:   []byte(`{"AccountNo":"A-1"}`),
+		Created:   at,
+		Updated:   at,
+		History:   []expresspay.StatusChange{{Status: expresspay.InvoiceStatusPendingPayment, At: at}},
+	}
+}
+
+func openFileStore(t *testing.T, path string) *expresspay.FileStore {
+	t.Helper()
+	s, err := expresspay.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestFileStoreReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	if err := s.SaveInvoice(ctx, storeRecord("1")); err != nil {
+		t.Fatal(err)
+	}
+	paidAt := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	if err := s.UpdateStatus(ctx, expresspay.InvoiceKindERIP, "1", expresspay.InvoiceStatusPaid, paidAt); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := s.LinkPayment(ctx, expresspay.InvoiceKindERIP, "1", "77"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.UpdateStatus(ctx, expresspay.InvoiceKindCard, "1", expresspay.InvoiceStatusPaid, paidAt); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	rec, err := openFileStore(t, path).Invoice(ctx, expresspay.InvoiceKindERIP, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != expresspay.InvoiceStatusPaid || len(rec.History) != 2 || !rec.Updated.Equal(paidAt) {
+		t.Errorf("status %v, history %+v", rec.Status, rec.History)
+	}
+	if len(rec.Payments) != 1 || rec.Payments[0] != "77" {
+		t.Errorf("payments %v", rec.Payments)
+	}
+	if _, err := openFileStore(t, path).Invoice(ctx, expresspay.InvoiceKindCard, "1"); err != expresspay.ErrNotStored {
+		t.Errorf("card invoice 1: err = %v", err)
+	}
+}
+
+func TestFileStoreSaveOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	rec := storeRecord("1")
+	if err := s.SaveInvoice(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	rec.Amount = expresspay.BYN(2000)
+	if err := s.SaveInvoice(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.InvoicesByAccount(ctx, "A-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || !recs[0].Amount.Equal(expresspay.BYN(2000)) {
+		t.Errorf("records %+v", recs)
+	}
+}
+
+// A crash mid-write leaves a torn last line. Reopening must cut it off, or the next append would
+// be glued to it and the log would no longer replay.
+func TestFileStoreTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	if err := s.SaveInvoice(ctx, storeRecord("1")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"save","record":{"kind":"invoice","invoi`)
+	f.Close()
+
+	s = openFileStore(t, path)
+	if err := s.SaveInvoice(ctx, storeRecord("2")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = openFileStore(t, path)
+	for _, no := range []string{"1", "2"} {
+		if _, err := s.Invoice(ctx, expresspay.InvoiceKindERIP, no); err != nil {
+			t.Errorf("invoice %s: %v", no, err)
+		}
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	tests := map[string]string{
+		"save without record": "{\"op\":\"save\"}\n",
+		"garbage before end":  "{\"op\":\n{\"op\":\"status\",\"kind\":\"invoice\",\"invoice_no\":\"1\",\"status\":\"3\",\"at\":\"2024-03-01T00:00:00Z\"}\n",
+	}
+	for name, content := range tests {
+		path := filepath.Join(t.TempDir(), "ledger.jsonl")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := expresspay.OpenFileStore(path)
+		if err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestStoreRecordsCardStatus(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	ledger := openFileStore(t, filepath.Join(t.TempDir(), "ledger.jsonl"))
+	c := srv.Client(expresspay.WithStore(ledger))
+	ctx := context.Background()
+
+	resp, err := c.CreateCardInvoice(ctx, expresspay.AddCardInvoiceRequest{
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(1000),
+		Info:      "Order A-1",
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no64, _ := resp.CardInvoiceNo.Int64()
+	if _, err := srv.Pay(int(no64), expresspay.Money{}); err != nil {
+		t.Fatal(err)
+	}
+	status, err := c.GetCardInvoiceStatus(ctx, int(no64), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Amount.Equal(expresspay.BYN(1000)) {
+		t.Errorf("status amount %v", status.Amount)
+	}
+	rec, err := ledger.Invoice(ctx, expresspay.InvoiceKindCard, resp.CardInvoiceNo.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != expresspay.InvoiceStatusPaidByBankCard {
+		t.Errorf("stored status %v", rec.Status)
+	}
+}
+
+// Money encodes only the number; the record keeps the currency beside it.
+func TestFileStoreKeepsCurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ctx := context.Background()
+	s := openFileStore(t, path)
+	rec := storeRecord("1")
+	rec.Amount = expresspay.NewMoney(1250, expresspay.CurrencyUSD)
+	if err := s.SaveInvoice(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	got, err := openFileStore(t, path).Invoice(ctx, expresspay.InvoiceKindERIP, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Amount.Equal(rec.Amount) {
+		t.Errorf("amount %+v after reopening, want %+v", got.Amount, rec.Amount)
+	}
+}
+
+// A change that could not be written must not show up in memory either.
+func TestFileStoreFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	s := openFileStore(t, filepath.Join(t.TempDir(), "ledger.jsonl"))
+	if err := s.SaveInvoice(ctx, storeRecord("1")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if err := s.UpdateStatus(ctx, expresspay.InvoiceKindERIP, "1", expresspay.InvoiceStatusPaid, time.Now()); err == nil {
+		t.Fatal("write to a closed log succeeded")
+	}
+	if err := s.LinkPayment(ctx, expresspay.InvoiceKindERIP, "1", "77"); err == nil {
+		t.Fatal("write to a closed log succeeded")
+	}
+	if err := s.SaveInvoice(ctx, storeRecord("2")); err == nil {
+		t.Fatal("write to a closed log succeeded")
+	}
+	rec, err := s.Invoice(ctx, expresspay.InvoiceKindERIP, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != expresspay.InvoiceStatusPendingPayment || len(rec.History) != 1 || len(rec.Payments) != 0 {
+		t.Errorf("unwritten changes applied: %+v", rec)
+	}
+	if _, err := s.Invoice(ctx, expresspay.InvoiceKindERIP, "2"); err != expresspay.ErrNotStored {
+		t.Errorf("unwritten invoice stored: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package expresspay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DateLayout     = "20060102"
+	DateTimeLayout = "200601021504"
+	// TimestampLayout is the layout of Created fields in responses.
+	TimestampLayout = "2006-01-02T15:04:05"
+)
+
+// Minsk is the zone the API uses for every date. Belarus has been on UTC+3 without DST since
+// 2011, which is used when the tz database is unavailable.
+var Minsk = loadMinsk()
+
+func loadMinsk() *time.Location {
+	loc, err := time.LoadLocation("Europe/Minsk")
+	if err != nil {
+		return time.FixedZone("Europe/Minsk", 3*60*60)
+	}
+	return loc
+}
+
+var timeLayouts = []string{
+	TimestampLayout,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"20060102150405",
+	DateTimeLayout,
+	DateLayout,
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// ParseTime parses any timestamp format returned by the API. Values without an offset are read
+// in the Minsk zone; values with one are converted to it.
+func ParseTime(s string) (time.Time, error) {
+	v := strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t.In(Minsk), nil
+	}
+	for _, layout := range timeLayouts {
+		if len(layout) != len(v) && !strings.Contains(layout, ".999") {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, v, Minsk); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expresspay: unparseable time %q", s)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Minsk).Format(DateLayout)
+}
+
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Minsk).Format(DateTimeLayout)
+}
+
+// Time is a timestamp decoded from an API response.
+type Time struct {
+	time.Time
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.In(Minsk).Format(TimestampLayout))
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	if strings.TrimSpace(s) == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Minsk).Format(TimestampLayout)
+}
@@ -0,0 +1,112 @@
+package expresspay_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+func TestParseTime(t *testing.T) {
+	// 10:30:15 in Minsk (UTC+3) is 07:30:15 UTC.
+	full := time.Date(2024, 3, 1, 7, 30, 15, 0, time.UTC)
+	minute := time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 2, 29, 21, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-03-01T10:30:15", full},
+		{"2024-03-01T10:30:15.250", full.Add(250 * time.Millisecond)},
+		{"2024-03-01 10:30:15", full},
+		{"2024-03-01T10:30", minute},
+		{"2024-03-01", day},
+		{"20240301103015", full},
+		{"202403011030", minute},
+		{"20240301", day},
+		{"01.03.2024 10:30:15", full},
+		{"01.03.2024 10:30", minute},
+		{"01.03.2024", day},
+		{" 2024-03-01T10:30:15 ", full},
+		{"2024-03-01T07:30:15Z", full},
+		{"2024-03-01T12:30:15+05:00", full},
+	}
+	for _, tt := range tests {
+		got, err := expresspay.ParseTime(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q = %v, want %v", tt.in, got.UTC(), tt.want)
+		}
+		if got.Location() != expresspay.Minsk {
+			t.Errorf("%q: location %v, want Minsk", tt.in, got.Location())
+		}
+	}
+
+	for _, in := range []string{"", "  ", "yesterday", "2024-13-01", "01/03/2024", "2024-03-01T10"} {
+		if got, err := expresspay.ParseTime(in); err == nil {
+			t.Errorf("%q: parsed as %v, want error", in, got)
+		}
+	}
+}
+
+// Minsk has had no DST since 2011; winter and summer dates are both UTC+3.
+func TestParseTimeNoDST(t *testing.T) {
+	for _, in := range []string{"2024-01-15T12:00:00", "2024-07-15T12:00:00"} {
+		got, err := expresspay.ParseTime(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.UTC().Hour() != 9 {
+			t.Errorf("%s is %v", in, got.UTC())
+		}
+	}
+}
+
+func TestTimeJSON(t *testing.T) {
+	var v struct{ Created expresspay.Time }
+	for _, in := range []string{`{"Created": "2024-03-01T10:30:15"}`, `{"Created": 20240301103015}`} {
+		v.Created = expresspay.Time{}
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if want := time.Date(2024, 3, 1, 7, 30, 15, 0, time.UTC); !v.Created.Equal(want) {
+			t.Errorf("%s: %v, want %v", in, v.Created.UTC(), want)
+		}
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Created":"2024-03-01T10:30:15"}` {
+		t.Errorf("encoded %s", out)
+	}
+	if v.Created.String() != "2024-03-01T10:30:15" {
+		t.Errorf("String() = %s", v.Created)
+	}
+
+	for _, in := range []string{`{"Created": null}`, `{"Created": ""}`, `{"Created": "  "}`} {
+		v.Created = expresspay.Time{Time: time.Now()}
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Errorf("%s: %v", in, err)
+		}
+		if !v.Created.IsZero() {
+			t.Errorf("%s: decoded %v, want zero", in, v.Created)
+		}
+	}
+	out, err = json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Created":null}` || v.Created.String() != "" {
+		t.Errorf("zero time encoded as %s, String %q", out, v.Created)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Created": "soon"}`), &v); err == nil {
+		t.Error("unparseable time accepted")
+	}
+}
@@ -1,6 +1,10 @@
 package expresspay
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
 
 const (
 	CurrencyBYN = "933"
@@ -9,16 +13,6 @@ const (
 	CurrencyRUB = "643"
 )
 
-const (
-	InvoiceStatusPendingPayment   = "1"
-	InvoiceStatusExpired          = "2"
-	InvoiceStatusPaid             = "3"
-	InvoiceStatusPartiallyPaid    = "4"
-	InvoiceStatusCanceled         = "5"
-	InvoiceStatusPaidByBankCard   = "6"
-	InvoiceStatusPaymentReturned  = "7"
-)
-
 const (
 	QRCodeViewTypeBase64 = "base64"
 	QRCodeViewTypeText   = "text"
@@ -38,9 +32,12 @@ func (e *APIError) Error() string {
 	if e == nil {
 		return "api error"
 	}
-	if e.ErrorCode != 0 || e.ErrorMessage != "" {
+	if e.ErrorMessage != "" {
 		return e.ErrorMessage
 	}
+	if e.ErrorCode != 0 {
+		return "expresspay: error code " + strconv.Itoa(e.ErrorCode)
+	}
 	if e.Msg != "" {
 		return e.Msg
 	}
@@ -51,17 +48,16 @@ func (e *APIError) Error() string {
 }
 
 type ListInvoicesParams struct {
-	From      string
-	To        string
+	From      time.Time
+	To        time.Time
 	AccountNo string
-	Status    string
+	Status    InvoiceStatus
 }
 
 type AddInvoiceRequest struct {
 	AccountNo         string
-	Amount            string
-	Currency          string
-	Expiration        string
+	Amount            Money
+	Expiration        time.Time
 	Info              string
 	Surname           string
 	FirstName         string
@@ -85,51 +81,59 @@ type AddInvoiceResponse struct {
 }
 
 type Invoice struct {
-	InvoiceNo     json.Number `json:"InvoiceNo"`
-	AccountNo     string      `json:"AccountNo"`
-	Status        json.Number `json:"Status"`
-	Created       string      `json:"Created"`
-	Expiration    string      `json:"Expiration"`
-	Amount        json.Number `json:"Amount"`
-	Currency      json.Number `json:"Currency"`
-	CardInvoiceNo json.Number `json:"CardInvoiceNo"`
+	InvoiceNo     json.Number   `json:"InvoiceNo"`
+	AccountNo     string        `json:"AccountNo"`
+	Status        InvoiceStatus `json:"Status"`
+	Created       Time          `json:"Created"`
+	Expiration    Time          `json:"Expiration"`
+	Amount        Money         `json:"Amount"`
+	Currency      json.Number   `json:"Currency"`
+	CardInvoiceNo json.Number   `json:"CardInvoiceNo"`
+}
+
+func (i *Invoice) fillCurrency() {
+	i.Amount.Currency = i.Currency.String()
 }
 
 type InvoiceDetails struct {
-	Status            json.Number `json:"Status"`
-	Created           string      `json:"Created"`
-	Expiration        string      `json:"Expiration"`
-	Amount            json.Number `json:"Amount"`
-	Currency          json.Number `json:"Currency"`
-	Info              string      `json:"Info"`
-	Surname           string      `json:"Surname"`
-	FirstName         string      `json:"FirstName"`
-	Patronymic        string      `json:"Patronymic"`
-	City              string      `json:"City"`
-	Street            string      `json:"Street"`
-	House             string      `json:"House"`
-	Building          string      `json:"Building"`
-	Apartment         string      `json:"Apartment"`
-	IsNameEditable    json.Number `json:"IsNameEditable"`
-	IsAddressEditable json.Number `json:"IsAddressEditable"`
-	IsAmountEditable  json.Number `json:"IsAmountEditable"`
+	Status            InvoiceStatus `json:"Status"`
+	Created           Time          `json:"Created"`
+	Expiration        Time          `json:"Expiration"`
+	Amount            Money         `json:"Amount"`
+	Currency          json.Number   `json:"Currency"`
+	Info              string        `json:"Info"`
+	Surname           string        `json:"Surname"`
+	FirstName         string        `json:"FirstName"`
+	Patronymic        string        `json:"Patronymic"`
+	City              string        `json:"City"`
+	Street            string        `json:"Street"`
+	House             string        `json:"House"`
+	Building          string        `json:"Building"`
+	Apartment         string        `json:"Apartment"`
+	IsNameEditable    json.Number   `json:"IsNameEditable"`
+	IsAddressEditable json.Number   `json:"IsAddressEditable"`
+	IsAmountEditable  json.Number   `json:"IsAmountEditable"`
+}
+
+func (i *InvoiceDetails) fillCurrency() {
+	i.Amount.Currency = i.Currency.String()
 }
 
 type InvoiceStatusResponse struct {
-	Status json.Number `json:"Status"`
+	Status InvoiceStatus `json:"Status"`
 }
 
 type ListPaymentsParams struct {
-	From      string
-	To        string
+	From      time.Time
+	To        time.Time
 	AccountNo string
 }
 
 type Payment struct {
 	PaymentNo  json.Number `json:"PaymentNo"`
 	AccountNo  string      `json:"AccountNo"`
-	Created    string      `json:"Created"`
-	Amount     json.Number `json:"Amount"`
+	Created    Time        `json:"Created"`
+	Amount     Money       `json:"Amount"`
 	Currency   json.Number `json:"Currency"`
 	Info       string      `json:"Info"`
 	Surname    string      `json:"Surname"`
@@ -142,10 +146,14 @@ type Payment struct {
 	Apartment  string      `json:"Apartment"`
 }
 
+func (p *Payment) fillCurrency() {
+	p.Amount.Currency = p.Currency.String()
+}
+
 type PaymentDetails struct {
 	AccountNo  string      `json:"AccountNo"`
-	Created    string      `json:"Created"`
-	Amount     json.Number `json:"Amount"`
+	Created    Time        `json:"Created"`
+	Amount     Money       `json:"Amount"`
 	Currency   json.Number `json:"Currency"`
 	Info       string      `json:"Info"`
 	Surname    string      `json:"Surname"`
@@ -158,6 +166,10 @@ type PaymentDetails struct {
 	Apartment  string      `json:"Apartment"`
 }
 
+func (p *PaymentDetails) fillCurrency() {
+	p.Amount.Currency = p.Currency.String()
+}
+
 type QRCodeParams struct {
 	ViewType    string
 	ImageWidth  string
@@ -170,15 +182,14 @@ type QRCodeResponse struct {
 
 type AddCardInvoiceRequest struct {
 	AccountNo          string
-	Expiration         string
-	Amount             string
-	Currency           string
+	Expiration         time.Time
+	Amount             Money
 	Info               string
 	ReturnURL          string
 	FailURL            string
 	Language           string
 	SessionTimeoutSecs string
-	ExpirationDate     string
+	ExpirationDate     time.Time
 	ReturnInvoiceURL   string
 }
 
@@ -210,10 +221,17 @@ func (r CardInvoiceFormResponse) check() error {
 }
 
 type CardInvoiceStatusResponse struct {
-	Amount            json.Number `json:"Amount"`
-	CardInvoiceStatus json.Number `json:"CardInvoiceStatus"`
-	ErrorCode         json.Number `json:"ErrorCode"`
-	ErrorMessage      string      `json:"ErrorMessage"`
+	Amount            Money             `json:"Amount"`
+	Currency          json.Number       `json:"Currency"`
+	CardInvoiceStatus CardInvoiceStatus `json:"CardInvoiceStatus"`
+	ErrorCode         json.Number       `json:"ErrorCode"`
+	ErrorMessage      string            `json:"ErrorMessage"`
+}
+
+// fillCurrency copies Currency into Amount. Older API versions omit Currency from the card
+// status, in which case Amount.Currency stays empty.
+func (r *CardInvoiceStatusResponse) fillCurrency() {
+	r.Amount.Currency = r.Currency.String()
 }
 
 func (r CardInvoiceStatusResponse) check() error {
@@ -238,12 +256,11 @@ func (r CardInvoiceReverseResponse) check() error {
 type AddWebInvoiceRequest struct {
 	ServiceID         string
 	AccountNo         string
-	Amount            string
-	Currency          string
+	Amount            Money
 	ReturnType        string
 	ReturnURL         string
 	FailURL           string
-	Expiration        string
+	Expiration        time.Time
 	Info              string
 	Surname           string
 	FirstName         string
@@ -272,16 +289,15 @@ type AddWebInvoiceResponse struct {
 type AddWebCardInvoiceRequest struct {
 	ServiceID          string
 	AccountNo          string
-	Expiration         string
-	Amount             string
-	Currency           string
+	Expiration         time.Time
+	Amount             Money
 	Info               string
 	ReturnType         string
 	ReturnURL          string
 	FailURL            string
 	Language           string
 	SessionTimeoutSecs string
-	ExpirationDate     string
+	ExpirationDate     time.Time
 	ReturnInvoiceURL   string
 }
 
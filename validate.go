@@ -0,0 +1,254 @@
+package expresspay
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError lists every invalid field of a request, in field order.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Error()
+	}
+	return "expresspay: invalid request: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Field(name string) *FieldError {
+	for i := range e.Errors {
+		if e.Errors[i].Field == name {
+			return &e.Errors[i]
+		}
+	}
+	return nil
+}
+
+func WithoutValidation() Option {
+	return func(c *Client) {
+		c.SkipValidation = true
+	}
+}
+
+var knownCurrencies = map[string]bool{
+	CurrencyBYN: true,
+	CurrencyEUR: true,
+	CurrencyUSD: true,
+	CurrencyRUB: true,
+}
+
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxLen(field, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		v.add(field, "must be at most %d characters", n)
+	}
+}
+
+func (v *validator) accountNo(value string) {
+	if v.required("AccountNo", value) {
+		v.maxLen("AccountNo", value, 30)
+	}
+}
+
+func (v *validator) amount(amount Money) {
+	if amount.Minor <= 0 {
+		v.add("Amount", "must be positive")
+	}
+	switch {
+	case amount.Currency == "":
+		v.add("Currency", "is required")
+	case !knownCurrencies[amount.Currency]:
+		v.add("Currency", "unknown currency code %q", amount.Currency)
+	}
+}
+
+func (v *validator) notPast(field string, t time.Time, layout func(time.Time) string) {
+	if t.IsZero() {
+		return
+	}
+	if layout(t) < layout(time.Now()) {
+		v.add(field, "is in the past")
+	}
+}
+
+func (v *validator) flag(field, value string) {
+	if value != "" && value != "0" && value != "1" {
+		v.add(field, "must be 0 or 1")
+	}
+}
+
+func (v *validator) email(value string) {
+	if value == "" {
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		v.add("EmailNotification", "is not a valid e-mail address")
+	}
+}
+
+// phone accepts Belarusian numbers in international format, with or without the leading "+".
+func (v *validator) phone(value string) {
+	if value == "" {
+		return
+	}
+	digits := strings.TrimPrefix(value, "+")
+	if len(digits) != 12 || !strings.HasPrefix(digits, "375") || !isDigits(digits) {
+		v.add("SmsPhone", "must be a Belarusian phone number like 375291234567")
+	}
+}
+
+func (v *validator) absURL(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		v.add(field, "must be an absolute URL")
+	}
+}
+
+func (v *validator) payer(surname, firstName, patronymic, city, street, house, building, apartment string) {
+	v.maxLen("Surname", surname, 30)
+	v.maxLen("FirstName", firstName, 30)
+	v.maxLen("Patronymic", patronymic, 30)
+	v.maxLen("City", city, 30)
+	v.maxLen("Street", street, 30)
+	v.maxLen("House", house, 18)
+	v.maxLen("Building", building, 10)
+	v.maxLen("Apartment", apartment, 10)
+}
+
+func (v *validator) sessionTimeout(value string) {
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 600 || n > 86400 {
+		v.add("SessionTimeoutSecs", "must be a number of seconds between 600 and 86400")
+	}
+}
+
+func (v *validator) returnType(value string) {
+	if value != "" && value != "json" && value != "redirect" {
+		v.add("ReturnType", "must be json or redirect")
+	}
+}
+
+func (v *validator) serviceID(value string) {
+	if v.required("ServiceId", value) && !isDigits(value) {
+		v.add("ServiceId", "must be numeric")
+	}
+}
+
+func (r AddInvoiceRequest) Validate() error {
+	var v validator
+	v.accountNo(r.AccountNo)
+	v.amount(r.Amount)
+	v.notPast("Expiration", r.Expiration, formatDate)
+	v.maxLen("Info", r.Info, 1024)
+	v.payer(r.Surname, r.FirstName, r.Patronymic, r.City, r.Street, r.House, r.Building, r.Apartment)
+	v.flag("IsNameEditable", r.IsNameEditable)
+	v.flag("IsAddressEditable", r.IsAddressEditable)
+	v.flag("IsAmountEditable", r.IsAmountEditable)
+	v.email(r.EmailNotification)
+	v.phone(r.SmsPhone)
+	v.flag("ReturnInvoiceUrl", r.ReturnInvoiceURL)
+	return v.err()
+}
+
+func (r AddCardInvoiceRequest) Validate() error {
+	var v validator
+	v.accountNo(r.AccountNo)
+	v.notPast("Expiration", r.Expiration, formatDate)
+	v.amount(r.Amount)
+	if v.required("Info", r.Info) {
+		v.maxLen("Info", r.Info, 1024)
+	}
+	v.absURL("ReturnUrl", r.ReturnURL, true)
+	v.absURL("FailUrl", r.FailURL, true)
+	v.sessionTimeout(r.SessionTimeoutSecs)
+	v.notPast("ExpirationDate", r.ExpirationDate, formatDateTime)
+	v.flag("ReturnInvoiceUrl", r.ReturnInvoiceURL)
+	return v.err()
+}
+
+func (r AddWebInvoiceRequest) Validate() error {
+	var v validator
+	v.serviceID(r.ServiceID)
+	v.accountNo(r.AccountNo)
+	v.amount(r.Amount)
+	v.notPast("Expiration", r.Expiration, formatDate)
+	v.maxLen("Info", r.Info, 1024)
+	v.payer(r.Surname, r.FirstName, r.Patronymic, r.City, r.Street, r.House, r.Building, r.Apartment)
+	v.flag("IsNameEditable", r.IsNameEditable)
+	v.flag("IsAddressEditable", r.IsAddressEditable)
+	v.flag("IsAmountEditable", r.IsAmountEditable)
+	v.email(r.EmailNotification)
+	v.phone(r.SmsPhone)
+	v.returnType(r.ReturnType)
+	v.absURL("ReturnUrl", r.ReturnURL, true)
+	v.absURL("FailUrl", r.FailURL, true)
+	v.flag("ReturnInvoiceUrl", r.ReturnInvoiceURL)
+	return v.err()
+}
+
+func (r AddWebCardInvoiceRequest) Validate() error {
+	var v validator
+	v.serviceID(r.ServiceID)
+	v.accountNo(r.AccountNo)
+	v.notPast("Expiration", r.Expiration, formatDate)
+	v.amount(r.Amount)
+	if v.required("Info", r.Info) {
+		v.maxLen("Info", r.Info, 1024)
+	}
+	v.returnType(r.ReturnType)
+	v.absURL("ReturnUrl", r.ReturnURL, true)
+	v.absURL("FailUrl", r.FailURL, true)
+	v.sessionTimeout(r.SessionTimeoutSecs)
+	v.notPast("ExpirationDate", r.ExpirationDate, formatDateTime)
+	v.flag("ReturnInvoiceUrl", r.ReturnInvoiceURL)
+	return v.err()
+}
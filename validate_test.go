@@ -0,0 +1,256 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+)
+
+// checkValidation reports whether err names exactly field, or is nil when field is empty.
+func checkValidation(t *testing.T, name string, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		return
+	}
+	var verr *expresspay.ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("%s: err = %v, want a ValidationError on %s", name, err, field)
+		return
+	}
+	if len(verr.Errors) != 1 || verr.Field(field) == nil {
+		t.Errorf("%s: %v, want only %s", name, err, field)
+	}
+}
+
+func validInvoice() expresspay.AddInvoiceRequest {
+	return expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)}
+}
+
+func validCardInvoice() expresspay.AddCardInvoiceRequest {
+	return expresspay.AddCardInvoiceRequest{
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(100),
+		Info:      "Order A-1",
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	}
+}
+
+func validWebInvoice() expresspay.AddWebInvoiceRequest {
+	return expresspay.AddWebInvoiceRequest{
+		ServiceID: "17",
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(100),
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	}
+}
+
+func validWebCardInvoice() expresspay.AddWebCardInvoiceRequest {
+	return expresspay.AddWebCardInvoiceRequest{
+		ServiceID: "17",
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(100),
+		Info:      "Order A-1",
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	}
+}
+
+func TestValidateInvoice(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -1)
+	tests := []struct {
+		name  string
+		edit  func(r *expresspay.AddInvoiceRequest)
+		field string
+	}{
+		{"valid", func(r *expresspay.AddInvoiceRequest) {}, ""},
+		{"all optional fields", func(r *expresspay.AddInvoiceRequest) {
+			r.Expiration = time.Now()
+			r.Info = strings.Repeat("и", 1024)
+			r.Surname = strings.Repeat("я", 30)
+			r.House = strings.Repeat("1", 18)
+			r.Building, r.Apartment = "1234567890", "1234567890"
+			r.IsNameEditable, r.IsAddressEditable, r.IsAmountEditable = "1", "0", "1"
+			r.EmailNotification = "payer@example.com"
+			r.SmsPhone = "+375291234567"
+			r.ReturnInvoiceURL = "1"
+		}, ""},
+		{"no account", func(r *expresspay.AddInvoiceRequest) { r.AccountNo = " " }, "AccountNo"},
+		{"long account", func(r *expresspay.AddInvoiceRequest) { r.AccountNo = strings.Repeat("1", 31) }, "AccountNo"},
+		{"zero amount", func(r *expresspay.AddInvoiceRequest) { r.Amount = expresspay.BYN(0) }, "Amount"},
+		{"negative amount", func(r *expresspay.AddInvoiceRequest) { r.Amount = expresspay.BYN(-1) }, "Amount"},
+		{"no currency", func(r *expresspay.AddInvoiceRequest) { r.Amount.Currency = "" }, "Currency"},
+		{"unknown currency", func(r *expresspay.AddInvoiceRequest) { r.Amount.Currency = "999" }, "Currency"},
+		{"past expiration", func(r *expresspay.AddInvoiceRequest) { r.Expiration = yesterday }, "Expiration"},
+		{"long info", func(r *expresspay.AddInvoiceRequest) { r.Info = strings.Repeat("и", 1025) }, "Info"},
+		{"long surname", func(r *expresspay.AddInvoiceRequest) { r.Surname = strings.Repeat("я", 31) }, "Surname"},
+		{"long first name", func(r *expresspay.AddInvoiceRequest) { r.FirstName = strings.Repeat("я", 31) }, "FirstName"},
+		{"long patronymic", func(r *expresspay.AddInvoiceRequest) { r.Patronymic = strings.Repeat("я", 31) }, "Patronymic"},
+		{"long city", func(r *expresspay.AddInvoiceRequest) { r.City = strings.Repeat("я", 31) }, "City"},
+		{"long street", func(r *expresspay.AddInvoiceRequest) { r.Street = strings.Repeat("я", 31) }, "Street"},
+		{"long house", func(r *expresspay.AddInvoiceRequest) { r.House = strings.Repeat("1", 19) }, "House"},
+		{"long building", func(r *expresspay.AddInvoiceRequest) { r.Building = strings.Repeat("1", 11) }, "Building"},
+		{"long apartment", func(r *expresspay.AddInvoiceRequest) { r.Apartment = strings.Repeat("1", 11) }, "Apartment"},
+		{"bad name flag", func(r *expresspay.AddInvoiceRequest) { r.IsNameEditable = "yes" }, "IsNameEditable"},
+		{"bad address flag", func(r *expresspay.AddInvoiceRequest) { r.IsAddressEditable = "2" }, "IsAddressEditable"},
+		{"bad amount flag", func(r *expresspay.AddInvoiceRequest) { r.IsAmountEditable = "true" }, "IsAmountEditable"},
+		{"bad e-mail", func(r *expresspay.AddInvoiceRequest) { r.EmailNotification = "payer at example.com" }, "EmailNotification"},
+		{"named e-mail", func(r *expresspay.AddInvoiceRequest) { r.EmailNotification = "Payer <payer@example.com>" }, "EmailNotification"},
+		{"foreign phone", func(r *expresspay.AddInvoiceRequest) { r.SmsPhone = "79161234567" }, "SmsPhone"},
+		{"short phone", func(r *expresspay.AddInvoiceRequest) { r.SmsPhone = "37529123456" }, "SmsPhone"},
+		{"bad return flag", func(r *expresspay.AddInvoiceRequest) { r.ReturnInvoiceURL = "https://shop.example" }, "ReturnInvoiceUrl"},
+	}
+	for _, tt := range tests {
+		r := validInvoice()
+		tt.edit(&r)
+		checkValidation(t, tt.name, r.Validate(), tt.field)
+	}
+}
+
+func TestValidateCardInvoice(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(r *expresspay.AddCardInvoiceRequest)
+		field string
+	}{
+		{"valid", func(r *expresspay.AddCardInvoiceRequest) {}, ""},
+		{"session bounds", func(r *expresspay.AddCardInvoiceRequest) { r.SessionTimeoutSecs = "600" }, ""},
+		{"no account", func(r *expresspay.AddCardInvoiceRequest) { r.AccountNo = "" }, "AccountNo"},
+		{"zero amount", func(r *expresspay.AddCardInvoiceRequest) { r.Amount.Minor = 0 }, "Amount"},
+		{"no info", func(r *expresspay.AddCardInvoiceRequest) { r.Info = "" }, "Info"},
+		{"long info", func(r *expresspay.AddCardInvoiceRequest) { r.Info = strings.Repeat("x", 1025) }, "Info"},
+		{"no return URL", func(r *expresspay.AddCardInvoiceRequest) { r.ReturnURL = "" }, "ReturnUrl"},
+		{"relative return URL", func(r *expresspay.AddCardInvoiceRequest) { r.ReturnURL = "/ok" }, "ReturnUrl"},
+		{"no fail URL", func(r *expresspay.AddCardInvoiceRequest) { r.FailURL = "" }, "FailUrl"},
+		{"hostless fail URL", func(r *expresspay.AddCardInvoiceRequest) { r.FailURL = "https:///fail" }, "FailUrl"},
+		{"short session", func(r *expresspay.AddCardInvoiceRequest) { r.SessionTimeoutSecs = "599" }, "SessionTimeoutSecs"},
+		{"long session", func(r *expresspay.AddCardInvoiceRequest) { r.SessionTimeoutSecs = "86401" }, "SessionTimeoutSecs"},
+		{"past expiration date", func(r *expresspay.AddCardInvoiceRequest) { r.ExpirationDate = time.Now().Add(-time.Hour) }, "ExpirationDate"},
+	}
+	for _, tt := range tests {
+		r := validCardInvoice()
+		tt.edit(&r)
+		checkValidation(t, tt.name, r.Validate(), tt.field)
+	}
+}
+
+func TestValidateWebInvoice(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(r *expresspay.AddWebInvoiceRequest)
+		field string
+	}{
+		{"valid", func(r *expresspay.AddWebInvoiceRequest) {}, ""},
+		{"redirect", func(r *expresspay.AddWebInvoiceRequest) { r.ReturnType = "redirect" }, ""},
+		{"no service", func(r *expresspay.AddWebInvoiceRequest) { r.ServiceID = "" }, "ServiceId"},
+		{"non-numeric service", func(r *expresspay.AddWebInvoiceRequest) { r.ServiceID = "shop" }, "ServiceId"},
+		{"long account", func(r *expresspay.AddWebInvoiceRequest) { r.AccountNo = strings.Repeat("1", 31) }, "AccountNo"},
+		{"zero amount", func(r *expresspay.AddWebInvoiceRequest) { r.Amount.Minor = 0 }, "Amount"},
+		{"bad return type", func(r *expresspay.AddWebInvoiceRequest) { r.ReturnType = "xml" }, "ReturnType"},
+		{"no return URL", func(r *expresspay.AddWebInvoiceRequest) { r.ReturnURL = "" }, "ReturnUrl"},
+		{"relative fail URL", func(r *expresspay.AddWebInvoiceRequest) { r.FailURL = "fail" }, "FailUrl"},
+		{"bad e-mail", func(r *expresspay.AddWebInvoiceRequest) { r.EmailNotification = "@" }, "EmailNotification"},
+	}
+	for _, tt := range tests {
+		r := validWebInvoice()
+		tt.edit(&r)
+		checkValidation(t, tt.name, r.Validate(), tt.field)
+	}
+}
+
+func TestValidateWebCardInvoice(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(r *expresspay.AddWebCardInvoiceRequest)
+		field string
+	}{
+		{"valid", func(r *expresspay.AddWebCardInvoiceRequest) {}, ""},
+		{"no service", func(r *expresspay.AddWebCardInvoiceRequest) { r.ServiceID = " " }, "ServiceId"},
+		{"no info", func(r *expresspay.AddWebCardInvoiceRequest) { r.Info = "" }, "Info"},
+		{"zero amount", func(r *expresspay.AddWebCardInvoiceRequest) { r.Amount.Minor = 0 }, "Amount"},
+		{"bad return type", func(r *expresspay.AddWebCardInvoiceRequest) { r.ReturnType = "html" }, "ReturnType"},
+		{"relative return URL", func(r *expresspay.AddWebCardInvoiceRequest) { r.ReturnURL = "ok" }, "ReturnUrl"},
+		{"no fail URL", func(r *expresspay.AddWebCardInvoiceRequest) { r.FailURL = "" }, "FailUrl"},
+		{"bad session", func(r *expresspay.AddWebCardInvoiceRequest) { r.SessionTimeoutSecs = "ten" }, "SessionTimeoutSecs"},
+	}
+	for _, tt := range tests {
+		r := validWebCardInvoice()
+		tt.edit(&r)
+		checkValidation(t, tt.name, r.Validate(), tt.field)
+	}
+}
+
+func TestValidationErrorListsEveryField(t *testing.T) {
+	err := expresspay.AddCardInvoiceRequest{}.Validate()
+	var verr *expresspay.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v", err)
+	}
+	var fields []string
+	for _, fe := range verr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	if got := strings.Join(fields, " "); got != "AccountNo Amount Currency Info ReturnUrl FailUrl" {
+		t.Errorf("fields %s", got)
+	}
+	if !strings.HasPrefix(err.Error(), "expresspay: invalid request: AccountNo: is required; ") {
+		t.Errorf("message %q", err)
+	}
+}
+
+func TestClientValidatesBeforeSending(t *testing.T) {
+	srv, hits := countingServer(t, func(n int32, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	c := expresspay.NewClient(srv.URL, "token", "secret")
+	ctx := context.Background()
+
+	invoice := validInvoice()
+	invoice.Amount.Minor = 0
+	card := validCardInvoice()
+	card.ReturnURL = ""
+	web := validWebInvoice()
+	web.ServiceID = ""
+	webCard := validWebCardInvoice()
+	webCard.Info = ""
+	calls := map[string]func() error{
+		"CreateInvoice": func() error {
+			_, err := c.CreateInvoice(ctx, invoice)
+			return err
+		},
+		"CreateInvoiceIdempotent": func() error {
+			_, err := c.CreateInvoiceIdempotent(ctx, invoice)
+			return err
+		},
+		"CreateCardInvoice": func() error {
+			_, err := c.CreateCardInvoice(ctx, card)
+			return err
+		},
+		"CreateWebInvoice": func() error {
+			_, err := c.CreateWebInvoice(ctx, web)
+			return err
+		},
+		"CreateWebCardInvoice": func() error {
+			_, err := c.CreateWebCardInvoice(ctx, webCard)
+			return err
+		},
+	}
+	for name, call := range calls {
+		var verr *expresspay.ValidationError
+		if err := call(); !errors.As(err, &verr) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Errorf("%d invalid requests reached the server", hits.Load())
+	}
+}
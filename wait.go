@@ -0,0 +1,105 @@
+package expresspay
+
+import (
+	"context"
+	"time"
+)
+
+// WaitOptions controls WaitForInvoice and WaitForCardInvoice. The poll interval starts at
+// Interval and grows by Multiplier up to MaxInterval while the status stays the same; it drops
+// back to Interval whenever the status changes.
+type WaitOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+
+	// Changes, if set, receives every distinct status observed by WaitForInvoice, including the
+	// final one. Sends block until received or ctx is done. The channel is never closed.
+	Changes chan<- InvoiceStatus
+	// CardChanges is Changes for WaitForCardInvoice.
+	CardChanges chan<- CardInvoiceStatus
+}
+
+const (
+	defaultWaitInterval    = 2 * time.Second
+	defaultWaitMaxInterval = 30 * time.Second
+	defaultWaitMultiplier  = 1.5
+)
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.Interval <= 0 {
+		o.Interval = defaultWaitInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = max(defaultWaitMaxInterval, o.Interval)
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = defaultWaitMultiplier
+	}
+	return o
+}
+
+// WaitForInvoice polls GetInvoiceStatus until the invoice reaches a final status (see
+// InvoiceStatus.IsFinal) and returns it. Transient errors (see IsRetryable) are polled through;
+// any other error, or ctx ending, stops the wait and is returned with the last status seen.
+func (c *Client) WaitForInvoice(ctx context.Context, invoiceNo int, opts WaitOptions) (InvoiceStatus, error) {
+	return waitForStatus(ctx, opts, opts.Changes, func(ctx context.Context) (InvoiceStatus, error) {
+		resp, err := c.GetInvoiceStatus(ctx, invoiceNo)
+		if err != nil {
+			return "", err
+		}
+		return resp.Status, nil
+	})
+}
+
+// WaitForCardInvoice is WaitForInvoice for card invoices, polling GetCardInvoiceStatus until
+// CardInvoiceStatus.IsFinal.
+func (c *Client) WaitForCardInvoice(ctx context.Context, cardInvoiceNo int, opts WaitOptions) (CardInvoiceStatus, error) {
+	return waitForStatus(ctx, opts, opts.CardChanges, func(ctx context.Context) (CardInvoiceStatus, error) {
+		resp, err := c.GetCardInvoiceStatus(ctx, cardInvoiceNo, "")
+		if err != nil {
+			return "", err
+		}
+		return resp.CardInvoiceStatus, nil
+	})
+}
+
+type finalStatus interface {
+	~string
+	IsFinal() bool
+}
+
+func waitForStatus[S finalStatus](ctx context.Context, opts WaitOptions, changes chan<- S, poll func(context.Context) (S, error)) (S, error) {
+	opts = opts.withDefaults()
+	var last S
+	interval := opts.Interval
+	for {
+		status, err := poll(ctx)
+		switch {
+		case err != nil && !IsRetryable(err):
+			return last, err
+		case err == nil && status != last:
+			last = status
+			interval = opts.Interval
+			if changes != nil {
+				select {
+				case changes <- status:
+				case <-ctx.Done():
+					return last, ctx.Err()
+				}
+			}
+		default:
+			interval = min(time.Duration(float64(interval)*opts.Multiplier), opts.MaxInterval)
+		}
+		if err == nil && status.IsFinal() {
+			return status, nil
+		}
+		wait := interval
+		if deadline, ok := ctx.Deadline(); ok {
+			wait = min(wait, time.Until(deadline))
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return last, err
+		}
+	}
+}
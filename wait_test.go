@@ -0,0 +1,81 @@
+package expresspay_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+var fastWait = expresspay.WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+func TestWaitForCardInvoice(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	c := srv.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.CreateCardInvoice(ctx, expresspay.AddCardInvoiceRequest{
+		AccountNo: "A-1",
+		Amount:    expresspay.BYN(1000),
+		Info:      "Order A-1",
+		ReturnURL: "https://shop.example/ok",
+		FailURL:   "https://shop.example/fail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no64, _ := resp.CardInvoiceNo.Int64()
+	no := int(no64)
+
+	changes := make(chan expresspay.CardInvoiceStatus)
+	opts := fastWait
+	opts.CardChanges = changes
+	var seen []expresspay.CardInvoiceStatus
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for s := range changes {
+			seen = append(seen, s)
+			if s == expresspay.CardInvoiceStatusRegistered {
+				srv.Pay(no, expresspay.Money{})
+			}
+		}
+	}()
+	status, err := c.WaitForCardInvoice(ctx, no, opts)
+	close(changes)
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != expresspay.CardInvoiceStatusAuthorized {
+		t.Errorf("status %v", status)
+	}
+	if len(seen) != 2 || seen[0] != expresspay.CardInvoiceStatusRegistered || seen[1] != status {
+		t.Errorf("changes %v", seen)
+	}
+}
+
+func TestWaitForInvoiceContext(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	resp, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: "A-1", Amount: expresspay.BYN(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, _ := resp.InvoiceNo.Int64()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	status, err := c.WaitForInvoice(ctx, int(no), fastWait)
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v", err)
+	}
+	if status != expresspay.InvoiceStatusPendingPayment {
+		t.Errorf("last status %v", status)
+	}
+}
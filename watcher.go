@@ -0,0 +1,275 @@
+package expresspay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWatchInterval = time.Minute
+	DefaultWatchLookback = 7 * 24 * time.Hour
+)
+
+// Checkpoint is what a Watcher remembers between polls: the last status of every invoice and
+// the payments already reported, each with its creation time so entries that fall out of the
+// lookback window can be pruned.
+type Checkpoint struct {
+	Invoices map[string]InvoiceCheckpoint `json:"invoices"`
+	Payments map[string]time.Time         `json:"payments"`
+	Polled   time.Time                    `json:"polled"`
+}
+
+type InvoiceCheckpoint struct {
+	Status  InvoiceStatus `json:"status"`
+	Created time.Time     `json:"created"`
+}
+
+// CheckpointStore persists a Watcher's Checkpoint. Load returns nil and no error when nothing has
+// been saved yet.
+type CheckpointStore interface {
+	Load(ctx context.Context) (*Checkpoint, error)
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp []byte
+}
+
+func (s *MemoryCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cp == nil {
+		return nil, nil
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(s.cp, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *MemoryCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cp = data
+	s.mu.Unlock()
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoint as a JSON file, replaced atomically on every save.
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (s FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s FileCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+// Watcher polls ListInvoices and ListPayments over the last Lookback and reports what changed
+// since the previous poll as events, for deployments that cannot receive notifications.
+//
+// The first poll without a saved checkpoint only records the current state, and is saved only if
+// it completes: a partial baseline would turn everything it missed into events on the next poll.
+// Events are delivered at least once: the checkpoint advances past an item only after every
+// subscriber accepted its event, so a failing subscriber sees it again on the next poll.
+type Watcher struct {
+	Client    *Client
+	Store     CheckpointStore
+	Interval  time.Duration
+	Lookback  time.Duration
+	AccountNo string
+	OnError   func(err error)
+
+	mu          sync.Mutex
+	subscribers []EventFunc
+}
+
+func (c *Client) Watcher(store CheckpointStore) *Watcher {
+	if store == nil {
+		store = &MemoryCheckpointStore{}
+	}
+	return &Watcher{
+		Client:   c,
+		Store:    store,
+		Interval: DefaultWatchInterval,
+		Lookback: DefaultWatchLookback,
+	}
+}
+
+func (w *Watcher) Subscribe(fn EventFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Run polls every Interval until ctx is done. Poll errors go to OnError and do not stop the loop.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := w.Poll(ctx); err != nil && ctx.Err() == nil && w.OnError != nil {
+			w.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Poll lists invoices and payments once, delivers events for the differences and saves the
+// checkpoint. After a failure the progress made so far is saved, unless this was the baseline.
+func (w *Watcher) Poll(ctx context.Context) error {
+	cp, err := w.Store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	baseline := cp == nil
+	if baseline {
+		cp = &Checkpoint{}
+	}
+	if cp.Invoices == nil {
+		cp.Invoices = map[string]InvoiceCheckpoint{}
+	}
+	if cp.Payments == nil {
+		cp.Payments = map[string]time.Time{}
+	}
+
+	lookback := w.Lookback
+	if lookback <= 0 {
+		lookback = DefaultWatchLookback
+	}
+	now := time.Now()
+	from := now.Add(-lookback)
+
+	err = w.pollInvoices(ctx, cp, from, now, baseline)
+	if err == nil {
+		err = w.pollPayments(ctx, cp, from, now, baseline)
+	}
+	if err == nil {
+		cp.Polled = now
+		cutoff := startOfDay(from)
+		for key, inv := range cp.Invoices {
+			if inv.Created.Before(cutoff) {
+				delete(cp.Invoices, key)
+			}
+		}
+		for key, created := range cp.Payments {
+			if created.Before(cutoff) {
+				delete(cp.Payments, key)
+			}
+		}
+	}
+	if err != nil && baseline {
+		return err
+	}
+	if saveErr := w.Store.Save(ctx, cp); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+func (w *Watcher) pollInvoices(ctx context.Context, cp *Checkpoint, from, to time.Time, baseline bool) error {
+	p := ListInvoicesParams{From: from, To: to, AccountNo: w.AccountNo}
+	for inv, err := range w.Client.InvoicesSeq(ctx, p) {
+		if err != nil {
+			return err
+		}
+		key := invoiceKey(inv)
+		prev, known := cp.Invoices[key]
+		if !baseline && (!known || prev.Status != inv.Status) {
+			var t EventType
+			switch {
+			case inv.Status.IsPaid() && !prev.Status.IsPaid():
+				t = EventInvoicePaid
+			case inv.Status == InvoiceStatusExpired:
+				t = EventInvoiceExpired
+			}
+			if t != "" {
+				if err := w.publish(ctx, invoiceEvent(t, &inv)); err != nil {
+					return err
+				}
+			}
+		}
+		cp.Invoices[key] = InvoiceCheckpoint{Status: inv.Status, Created: inv.Created.Time}
+	}
+	return nil
+}
+
+func (w *Watcher) pollPayments(ctx context.Context, cp *Checkpoint, from, to time.Time, baseline bool) error {
+	p := ListPaymentsParams{From: from, To: to, AccountNo: w.AccountNo}
+	for pay, err := range w.Client.PaymentsSeq(ctx, p) {
+		if err != nil {
+			return err
+		}
+		key := paymentKey(pay)
+		if _, seen := cp.Payments[key]; seen {
+			continue
+		}
+		if !baseline {
+			if err := w.publish(ctx, paymentEvent(&pay)); err != nil {
+				return err
+			}
+		}
+		cp.Payments[key] = pay.Created.Time
+	}
+	return nil
+}
+
+func (w *Watcher) publish(ctx context.Context, e Event) error {
+	w.mu.Lock()
+	subscribers := append([]EventFunc(nil), w.subscribers...)
+	w.mu.Unlock()
+	for _, fn := range subscribers {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+// eventLog records the events a Watcher delivers as "<type> <number>".
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) record(ctx context.Context, e expresspay.Event) error {
+	no := e.InvoiceNo
+	if e.Type == expresspay.EventPaymentReceived {
+		no = e.PaymentNo
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprintf("%s %s", e.Type, no))
+	return nil
+}
+
+// take returns the events recorded since the previous call.
+func (l *eventLog) take() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := fmt.Sprint(l.events)
+	l.events = nil
+	return s
+}
+
+// paidInvoice creates an invoice and pays it in full, returning the invoice and payment numbers.
+func paidInvoice(t *testing.T, srv *expresspaytest.Server, c *expresspay.Client, account string) (int, int) {
+	t.Helper()
+	resp, err := c.CreateInvoice(context.Background(), expresspay.AddInvoiceRequest{AccountNo: account, Amount: expresspay.BYN(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	no, _ := resp.InvoiceNo.Int64()
+	paymentNo, err := srv.Pay(int(no), expresspay.Money{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(no), paymentNo
+}
+
+func TestWatcherBaseline(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	paidInvoice(t, srv, c, "A-1")
+
+	w := c.Watcher(nil)
+	var log eventLog
+	w.Subscribe(log.record)
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.take(); got != "[]" {
+		t.Errorf("baseline delivered %s", got)
+	}
+
+	inv, pay := paidInvoice(t, srv, c, "A-2")
+	pending, err := c.CreateInvoice(ctx, expresspay.AddInvoiceRequest{AccountNo: "A-3", Amount: expresspay.BYN(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := pending.InvoiceNo.Int64()
+	if err := srv.Expire(int(expired)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("[InvoicePaid %d InvoiceExpired %d PaymentReceived %d]", inv, expired, pay)
+	if got := log.take(); got != want {
+		t.Errorf("events %s, want %s", got, want)
+	}
+
+	// Nothing changed: nothing is delivered again.
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.take(); got != "[]" {
+		t.Errorf("repeated poll delivered %s", got)
+	}
+}
+
+// A baseline that fails halfway must not be saved: the payments it missed would otherwise be
+// reported as new on the next poll.
+func TestWatcherFailedBaseline(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	paidInvoice(t, srv, c, "A-1")
+
+	store := &expresspay.MemoryCheckpointStore{}
+	w := c.Watcher(store)
+	var log eventLog
+	w.Subscribe(log.record)
+	srv.FailNext("get-list-payments", expresspay.APIError{Code: 42, Msg: "down", HTTPStatus: http.StatusBadRequest})
+	if err := w.Poll(ctx); err == nil {
+		t.Fatal("want error")
+	}
+	if cp, err := store.Load(ctx); err != nil || cp != nil {
+		t.Fatalf("failed baseline saved: %+v, %v", cp, err)
+	}
+
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.take(); got != "[]" {
+		t.Errorf("retried baseline delivered %s", got)
+	}
+}
+
+// After the baseline, a failing poll keeps the progress made before the failure.
+func TestWatcherFailedPoll(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	w := c.Watcher(nil)
+	var log eventLog
+	w.Subscribe(log.record)
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	inv, pay := paidInvoice(t, srv, c, "A-1")
+	srv.FailNext("get-list-payments", expresspay.APIError{Code: 42, Msg: "down", HTTPStatus: http.StatusBadRequest})
+	if err := w.Poll(ctx); err == nil {
+		t.Fatal("want error")
+	}
+	if got, want := log.take(), fmt.Sprintf("[InvoicePaid %d]", inv); got != want {
+		t.Errorf("failed poll delivered %s, want %s", got, want)
+	}
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := log.take(), fmt.Sprintf("[PaymentReceived %d]", pay); got != want {
+		t.Errorf("next poll delivered %s, want %s", got, want)
+	}
+}
+
+func TestWatcherRestartFromCheckpoint(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	store := expresspay.FileCheckpointStore{Path: filepath.Join(t.TempDir(), "watch.json")}
+	paidInvoice(t, srv, c, "A-1")
+
+	if err := c.Watcher(store).Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	inv, pay := paidInvoice(t, srv, c, "A-2")
+
+	// A new process picks up where the old one stopped.
+	w := c.Watcher(store)
+	var log eventLog
+	w.Subscribe(log.record)
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := log.take(), fmt.Sprintf("[InvoicePaid %d PaymentReceived %d]", inv, pay); got != want {
+		t.Errorf("events %s, want %s", got, want)
+	}
+	w = c.Watcher(store)
+	w.Subscribe(log.record)
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.take(); got != "[]" {
+		t.Errorf("events delivered again after restart: %s", got)
+	}
+}
+
+// An event a subscriber rejected is delivered again, and only that one.
+func TestWatcherRedeliversRejectedEvents(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	w := c.Watcher(nil)
+	var log eventLog
+	reject := true
+	w.Subscribe(func(ctx context.Context, e expresspay.Event) error {
+		if reject && e.Type == expresspay.EventPaymentReceived {
+			return errors.New("busy")
+		}
+		return log.record(ctx, e)
+	})
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	inv, pay := paidInvoice(t, srv, c, "A-1")
+	if err := w.Poll(ctx); err == nil {
+		t.Fatal("want the subscriber's error")
+	}
+	if got, want := log.take(), fmt.Sprintf("[InvoicePaid %d]", inv); got != want {
+		t.Errorf("events %s, want %s", got, want)
+	}
+	reject = false
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := log.take(), fmt.Sprintf("[PaymentReceived %d]", pay); got != want {
+		t.Errorf("redelivered %s, want %s", got, want)
+	}
+}
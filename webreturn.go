@@ -0,0 +1,143 @@
+package expresspay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	ErrReturnSignature = errors.New("expresspay: return signature mismatch")
+	ErrReturnRejected  = errors.New("expresspay: returned invoice is not in a successful status")
+)
+
+// WebReturn describes a customer redirected back to ReturnUrl or FailUrl after a web invoice.
+type WebReturn struct {
+	AccountNumber string
+	InvoiceNo     int
+	Signature     string
+	Card          bool
+	Status        InvoiceStatus
+	// CardStatus is the status reported by GetCardInvoiceStatus for card returns; Status then
+	// holds its InvoiceStatus mapping.
+	CardStatus CardInvoiceStatus
+}
+
+// Successful reports whether the invoice behind the return is in order: paid for card invoices,
+// paid or still payable for ERIP invoices.
+func (w *WebReturn) Successful() bool {
+	if w.Card {
+		return w.CardStatus.IsPaid()
+	}
+	return w.Status.IsPaid() || !w.Status.IsFinal()
+}
+
+// ParseWebReturn reads and verifies the redirect parameters without contacting the API.
+func (c *Client) ParseWebReturn(r *http.Request, card bool) (*WebReturn, error) {
+	q := r.URL.Query()
+	ret := &WebReturn{
+		AccountNumber: q.Get("ExpressPayAccountNumber"),
+		Signature:     q.Get("Signature"),
+		Card:          card,
+	}
+	no, err := strconv.Atoi(q.Get("ExpressPayInvoiceNo"))
+	if err != nil || ret.AccountNumber == "" {
+		return ret, fmt.Errorf("%w: missing ExpressPayAccountNumber or ExpressPayInvoiceNo", ErrInvalidRequest)
+	}
+	ret.InvoiceNo = no
+
+	if c.UseSignature || c.Secret != "" {
+		action := "add-web-invoice-response"
+		if card {
+			action = "add-webcard-invoice-response"
+		}
+		sigParams := map[string]string{
+			"ExpressPayAccountNumber": ret.AccountNumber,
+			"ExpressPayInvoiceNo":     strconv.Itoa(no),
+		}
+		if err := c.checkSignature(action, sigParams, ret.Signature); err != nil {
+			if errors.Is(err, ErrResponseSignatureMismatch) {
+				return ret, ErrReturnSignature
+			}
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+// ResolveWebReturn parses the redirect and asks the API for the invoice status, so a forged or
+// replayed redirect cannot mark an order as paid.
+func (c *Client) ResolveWebReturn(ctx context.Context, r *http.Request, card bool) (*WebReturn, error) {
+	ret, err := c.ParseWebReturn(r, card)
+	if err != nil {
+		return ret, err
+	}
+	if card {
+		resp, err := c.GetCardInvoiceStatus(ctx, ret.InvoiceNo, "")
+		if err != nil {
+			return ret, err
+		}
+		ret.CardStatus = resp.CardInvoiceStatus
+		ret.Status = resp.CardInvoiceStatus.InvoiceStatus()
+	} else {
+		resp, err := c.GetInvoiceStatus(ctx, ret.InvoiceNo)
+		if err != nil {
+			return ret, err
+		}
+		ret.Status = resp.Status
+	}
+	if !ret.Successful() {
+		if card {
+			return ret, fmt.Errorf("%w: %s", ErrReturnRejected, ret.CardStatus)
+		}
+		return ret, fmt.Errorf("%w: %s", ErrReturnRejected, ret.Status)
+	}
+	return ret, nil
+}
+
+type WebReturnHandler struct {
+	Client *Client
+	Card   bool
+
+	OnSuccess func(w http.ResponseWriter, r *http.Request, ret *WebReturn)
+	OnFailure func(w http.ResponseWriter, r *http.Request, ret *WebReturn, err error)
+}
+
+// WebReturnHandler returns a handler for the ReturnUrl/FailUrl of CreateWebInvoice (card=false)
+// or CreateWebCardInvoice (card=true).
+func (c *Client) WebReturnHandler(card bool) *WebReturnHandler {
+	return &WebReturnHandler{Client: c, Card: card}
+}
+
+func (h *WebReturnHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ret, err := h.Client.ResolveWebReturn(r.Context(), r, h.Card)
+	if err != nil {
+		if h.OnFailure != nil {
+			h.OnFailure(w, r, ret, err)
+			return
+		}
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrReturnSignature):
+			status = http.StatusForbidden
+		case errors.Is(err, ErrReturnRejected):
+			status = http.StatusPaymentRequired
+		case IsRetryable(err):
+			status = http.StatusBadGateway
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if h.OnSuccess != nil {
+		h.OnSuccess(w, r, ret)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,68 @@
+package expresspay_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dizel-by/expresspay"
+	"github.com/dizel-by/expresspay/expresspaytest"
+)
+
+func TestResolveWebReturnCard(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	req := expresspay.AddWebCardInvoiceRequest{
+		ServiceID:  "17",
+		AccountNo:  "order-1",
+		Amount:     expresspay.BYN(1500),
+		Info:       "Order 1",
+		ReturnType: "json",
+		ReturnURL:  "https://shop.example/ok",
+		FailURL:    "https://shop.example/fail",
+	}
+	resp, err := c.CreateWebCardInvoice(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	no64, _ := resp.ExpressPayInvoiceNo.Int64()
+	no := int(no64)
+	returnURL, err := srv.ReturnURL(no, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := c.ResolveWebReturn(ctx, httptest.NewRequest("GET", returnURL, nil), true)
+	if !errors.Is(err, expresspay.ErrReturnRejected) {
+		t.Fatalf("unpaid card invoice: err = %v", err)
+	}
+	if ret.CardStatus != expresspay.CardInvoiceStatusRegistered || ret.Status != expresspay.InvoiceStatusPendingPayment {
+		t.Errorf("unpaid card invoice: %+v", ret)
+	}
+
+	if _, err := srv.Pay(no, expresspay.Money{}); err != nil {
+		t.Fatal(err)
+	}
+	ret, err = c.ResolveWebReturn(ctx, httptest.NewRequest("GET", returnURL, nil), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.CardStatus != expresspay.CardInvoiceStatusAuthorized || ret.Status != expresspay.InvoiceStatusPaidByBankCard || !ret.Successful() {
+		t.Errorf("paid card invoice: %+v", ret)
+	}
+}
+
+func TestParseWebReturnSignature(t *testing.T) {
+	srv := expresspaytest.NewServer("token", "secret")
+	defer srv.Close()
+	c := srv.Client()
+
+	r := httptest.NewRequest("GET", "https://shop.example/ok?ExpressPayAccountNumber=order-1&ExpressPayInvoiceNo=1&Signature=00", nil)
+	if _, err := c.ParseWebReturn(r, false); !errors.Is(err, expresspay.ErrReturnSignature) {
+		t.Errorf("forged return: err = %v", err)
+	}
+}